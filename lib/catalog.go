@@ -0,0 +1,144 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// The catalog is a gzipped stream of
+//
+//	magic
+//	uvarint(number of metadata), {uvarint(len(key)), key, uvarint(len(value)), value}...
+//	{MsgID.MarshalBinary(), uvarint(len(data)), MsgData.MarshalBinary()}... till EOF
+const catalogMagic = "oerr\x01"
+
+// WriteCatalog writes the metadata and the messages as a compressed catalog, readable by ReadCatalog.
+func WriteCatalog(w io.Writer, meta map[string]string, msgs iter.Seq2[Message, error]) error {
+	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(zw)
+	bw.WriteString(catalogMagic)
+	var a [binary.MaxVarintLen64]byte
+	writeBytes := func(p []byte) {
+		bw.Write(a[:binary.PutUvarint(a[:], uint64(len(p)))])
+		bw.Write(p)
+	}
+	bw.Write(a[:binary.PutUvarint(a[:], uint64(len(meta)))])
+	for k, v := range meta {
+		writeBytes([]byte(k))
+		writeBytes([]byte(v))
+	}
+	for msg, err := range msgs {
+		if err != nil {
+			return err
+		}
+		key, err := msg.MsgID.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		val, err := msg.MsgData.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		bw.Write(key)
+		writeBytes(val)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadCatalog reads the catalog written by WriteCatalog into a MemDB.
+func ReadCatalog(r io.Reader) (*MemDB, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	br := bufio.NewReader(zr)
+	magic := make([]byte, len(catalogMagic))
+	if _, err = io.ReadFull(br, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != catalogMagic {
+		return nil, fmt.Errorf("bad catalog magic %q", magic)
+	}
+	readBytes := func() ([]byte, error) {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		p := make([]byte, n)
+		_, err = io.ReadFull(br, p)
+		return p, err
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	meta := make(map[string]string, n)
+	for ; n > 0; n-- {
+		k, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		v, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		meta[string(k)] = string(v)
+	}
+	return NewMemDB(meta, func(yield func(Message, error) bool) {
+		key := make([]byte, 7)
+		for {
+			if _, err := io.ReadFull(br, key); err != nil {
+				if err != io.EOF {
+					yield(Message{}, err)
+				}
+				return
+			}
+			var msg Message
+			err := msg.MsgID.UnmarshalBinary(key)
+			var val []byte
+			if err == nil {
+				val, err = readBytes()
+			}
+			if err == nil {
+				err = msg.MsgData.UnmarshalBinary(val)
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				yield(Message{}, err)
+				return
+			}
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	})
+}
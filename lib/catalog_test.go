@@ -0,0 +1,46 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCatalog(&buf, map[string]string{MetaURL: URL}, seqOf(testMsgs...)); err != nil {
+		t.Fatal(err)
+	}
+	db, err := ReadCatalog(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Meta()[MetaURL] != URL {
+		t.Errorf("meta: got %v", db.Meta())
+	}
+	for _, msg := range testMsgs {
+		if data, err := db.Get(msg.MsgID); err != nil || data != msg.MsgData {
+			t.Errorf("Get(%s): got %v, %v", msg.MsgID, data, err)
+		}
+	}
+	if got := collect(t, db.Range(MsgID{"ORA", 1501}, MsgID{"ORA", 1600})); len(got) != 2 {
+		t.Errorf("Range: got %v", got)
+	}
+	if got := collect(t, db.Prefix("PLS")); len(got) != 1 {
+		t.Errorf("Prefix: got %v", got)
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Class is a set of error classes.
+type Class uint32
+
+const (
+	// ClassRetryable errors can be retried in the same session, after a rollback
+	// (deadlock, serialization failure, snapshot too old).
+	ClassRetryable = Class(1 << iota)
+	// ClassTransient errors are temporary shortages of resources, to be retried later.
+	ClassTransient
+	// ClassConnection errors mean the connection is lost: reconnect, then retry.
+	ClassConnection
+	// ClassTimeout errors are timeouts and cancellations.
+	ClassTimeout
+	// ClassConstraint errors are integrity constraint violations.
+	ClassConstraint
+	// ClassPermission errors are missing privileges and failed logins.
+	ClassPermission
+	// ClassNoData is no data found.
+	ClassNoData
+	// ClassData errors are invalid or too large values.
+	ClassData
+)
+
+var classNames = []string{"retryable", "transient", "connection", "timeout", "constraint", "permission", "nodata", "data"}
+
+// ClassNames are the names of the classes, as accepted by ParseClass.
+func ClassNames() []string { return append([]string(nil), classNames...) }
+
+// String returns the names of the classes, separated by commas, or "none".
+func (c Class) String() string {
+	if c == 0 {
+		return "none"
+	}
+	var names []string
+	for i, nm := range classNames {
+		if c&(1<<i) != 0 {
+			names = append(names, nm)
+		}
+	}
+	if rest := c &^ (1<<len(classNames) - 1); rest != 0 {
+		names = append(names, fmt.Sprintf("%#x", uint32(rest)))
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseClass parses the class names, separated by commas or spaces.
+func ParseClass(s string) (Class, error) {
+	var c Class
+	for _, nm := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		nm = strings.ToLower(nm)
+		if nm == "none" {
+			continue
+		}
+		i := slices.Index(classNames, nm)
+		if i < 0 {
+			return c, fmt.Errorf("unknown class %q (known: %s)", nm, strings.Join(classNames, ", "))
+		}
+		c |= 1 << i
+	}
+	return c, nil
+}
+
+// builtinClasses is the curated classification.
+var builtinClasses = func() map[MsgID]Class {
+	m := make(map[MsgID]Class)
+	for c, codes := range map[Class][]uint32{
+		ClassRetryable: {
+			60,   // deadlock detected while waiting for resource
+			1555, // snapshot too old
+			2049, // timeout: distributed transaction waiting for lock
+			4061, // existing state of string has been invalidated
+			4068, // existing state of packages has been discarded
+			8177, // can't serialize access for this transaction
+		},
+		ClassTransient: {
+			18,    // maximum number of sessions exceeded
+			20,    // maximum number of processes exceeded
+			51,    // timeout occurred while waiting for a resource
+			54,    // resource busy and acquire with NOWAIT specified or timeout expired
+			4021,  // timeout occurred while waiting to lock object
+			4031,  // unable to allocate number bytes of shared memory
+			12516, // TNS:listener could not find available handler with matching protocol stack
+			12518, // TNS:listener could not hand off client connection
+			12519, // TNS:no appropriate service handler found
+			12520, // TNS:listener could not find available handler for requested type of server
+			12528, // TNS:listener: all appropriate instances are blocking new connections
+			30006, // resource busy; acquire with WAIT timeout expired
+		},
+		ClassConnection: {
+			28,    // your session has been killed
+			1012,  // not logged on
+			1033,  // ORACLE initialization or shutdown in progress
+			1034,  // ORACLE not available
+			1089,  // immediate shutdown or close in progress
+			1090,  // shutdown in progress
+			1092,  // ORACLE instance terminated. Disconnection forced
+			2396,  // exceeded maximum idle time, please connect again
+			3113,  // end-of-file on communication channel
+			3114,  // not connected to ORACLE
+			3135,  // connection lost contact
+			12170, // TNS:Connect timeout occurred
+			12514, // TNS:listener does not currently know of service requested in connect descriptor
+			12516, 12518, 12519, 12520, 12528,
+			12537, // TNS:connection closed
+			12541, // TNS:no listener
+			12543, // TNS:destination host unreachable
+			12547, // TNS:lost contact
+			12571, // TNS:packet writer failure
+			25408, // can not safely replay call
+		},
+		ClassTimeout: {
+			51, 2049, 4021, 30006,
+			1013,  // user requested cancel of current operation
+			12170, // TNS:Connect timeout occurred
+			12535, // TNS:operation timed out
+		},
+		ClassConstraint: {
+			1,    // unique constraint (string.string) violated
+			1400, // cannot insert NULL into (string)
+			1407, // cannot update (string) to NULL
+			2290, // check constraint (string.string) violated
+			2291, // integrity constraint (string.string) violated - parent key not found
+			2292, // integrity constraint (string.string) violated - child record found
+		},
+		ClassPermission: {
+			1017,  // invalid username/password; logon denied
+			1031,  // insufficient privileges
+			1045,  // user string lacks CREATE SESSION privilege; logon denied
+			28000, // the account is locked
+			28001, // the password has expired
+		},
+		ClassNoData: {
+			1403, // no data found
+		},
+		ClassData: {
+			1426,  // numeric overflow
+			1438,  // value larger than specified precision allowed for this column
+			1476,  // divisor is equal to zero
+			1722,  // invalid number
+			1830,  // date format picture ends before converting entire input string
+			1843,  // not a valid month
+			1858,  // a non-numeric character was found where a numeric was expected
+			1861,  // literal does not match format string
+			6502,  // PL/SQL: numeric or value error
+			12899, // value too large for column string (actual: number, maximum: number)
+		},
+	} {
+		for _, code := range codes {
+			m[MsgID{Prefix: "ORA", Code: code}] |= c
+			if 12150 <= code && code < 12700 {
+				m[MsgID{Prefix: "TNS", Code: code}] |= c
+			}
+		}
+	}
+	return m
+}()
+
+var registeredClasses struct {
+	sync.RWMutex
+	m map[MsgID]Class
+}
+
+// SetClass registers the class of the message, overriding the built-in classification.
+// Zero class means no class.
+func SetClass(id MsgID, c Class) {
+	registeredClasses.Lock()
+	defer registeredClasses.Unlock()
+	if registeredClasses.m == nil {
+		registeredClasses.m = make(map[MsgID]Class)
+	}
+	registeredClasses.m[id] = c
+}
+
+// Classify the message by SetClass, or by the built-in classification.
+func Classify(id MsgID) Class {
+	registeredClasses.RLock()
+	c, ok := registeredClasses.m[id]
+	registeredClasses.RUnlock()
+	if ok {
+		return c
+	}
+	return builtinClasses[id]
+}
+
+// Classes returns all the classified messages, the built-in and the registered ones.
+func Classes() map[MsgID]Class {
+	m := maps.Clone(builtinClasses)
+	registeredClasses.RLock()
+	defer registeredClasses.RUnlock()
+	for id, c := range registeredClasses.m {
+		if c == 0 {
+			delete(m, id)
+		} else {
+			m[id] = c
+		}
+	}
+	return m
+}
+
+// ClassLister is implemented by the DBs storing classes alongside the catalog.
+type ClassLister interface {
+	// StoredClasses returns the classes stored in the DB.
+	StoredClasses() (map[MsgID]Class, error)
+}
+
+// LoadClasses registers the classes stored in the DB, with SetClass.
+func LoadClasses(db DB) error {
+	cl, ok := db.(ClassLister)
+	if !ok {
+		return nil
+	}
+	m, err := cl.StoredClasses()
+	for id, c := range m {
+		SetClass(id, c)
+	}
+	return err
+}
+
+// ClassifyIn classifies the message by the classes stored in the DB, or by Classify.
+func ClassifyIn(db DB, id MsgID) Class {
+	if cl, ok := db.(ClassLister); ok {
+		if m, err := cl.StoredClasses(); err == nil {
+			if c, ok := m[id]; ok {
+				return c
+			}
+		}
+	}
+	return Classify(id)
+}
+
+// ClassOf returns the class of the Oracle error in err (see FromError), or zero.
+func ClassOf(err error) Class {
+	if oe := FromError(err); oe != nil {
+		return Classify(oe.ID)
+	}
+	return 0
+}
+
+// IsRetryable reports whether the error can be retried in the same session:
+// a retryable or a transient one.
+func IsRetryable(err error) bool { return ClassOf(err)&(ClassRetryable|ClassTransient) != 0 }
+
+// IsTransient reports whether the error is a temporary shortage of resources.
+func IsTransient(err error) bool { return ClassOf(err)&ClassTransient != 0 }
+
+// IsConnectionError reports whether the connection is lost, and has to be reopened.
+func IsConnectionError(err error) bool { return ClassOf(err)&ClassConnection != 0 }
+
+// IsTimeout reports whether the error is a timeout or a cancellation.
+func IsTimeout(err error) bool { return ClassOf(err)&ClassTimeout != 0 }
+
+// IsConstraintViolation reports whether the error is an integrity constraint violation.
+func IsConstraintViolation(err error) bool { return ClassOf(err)&ClassConstraint != 0 }
+
+// IsPermissionError reports whether the error is a missing privilege or a failed login.
+func IsPermissionError(err error) bool { return ClassOf(err)&ClassPermission != 0 }
+
+// IsNoData reports whether the error is "no data found".
+func IsNoData(err error) bool { return ClassOf(err)&ClassNoData != 0 }
+
+// IsDataError reports whether the error is an invalid or too large value.
+func IsDataError(err error) bool { return ClassOf(err)&ClassData != 0 }
+
+// classesBucketName holds the classes, keyed by MsgID, the value is Class.String.
+const classesBucketName = "classes"
+
+var _ ClassLister = (*dbS)(nil)
+
+func (db *dbS) StoredClasses() (map[MsgID]Class, error) {
+	m := make(map[MsgID]Class)
+	err := db.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(classesBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var id MsgID
+			if err := id.UnmarshalBinary(k); err != nil {
+				return err
+			}
+			c, err := ParseClass(string(v))
+			if err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			m[id] = c
+			return nil
+		})
+	})
+	return m, err
+}
+
+// StoredClasses of all the layers, the upper ones overriding the lower ones.
+func (l *Layered) StoredClasses() (map[MsgID]Class, error) {
+	m := make(map[MsgID]Class)
+	for i := len(l.Layers) - 1; i >= 0; i-- {
+		if cl, ok := l.Layers[i].DB.(ClassLister); ok {
+			lm, err := cl.StoredClasses()
+			if err != nil {
+				return m, err
+			}
+			maps.Copy(m, lm)
+		}
+	}
+	return m, nil
+}
+
+func (r *ReloadingDB) StoredClasses() (map[MsgID]Class, error) {
+	g, err := r.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer g.inFlight.Done()
+	return g.StoredClasses()
+}
+
+// SetClass stores the class of the message, overriding the built-in classification.
+func (w *Writer) SetClass(id MsgID, c Class) error {
+	key, err := id.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return w.update(func(bucket *bolt.Bucket) error {
+		classes, err := bucket.Tx().CreateBucketIfNotExists([]byte(classesBucketName))
+		if err != nil {
+			return err
+		}
+		return classes.Put(key, []byte(c.String()))
+	})
+}
+
+// DeleteClass deletes the stored class of the message, reverting to the built-in classification.
+func (w *Writer) DeleteClass(id MsgID) error {
+	key, err := id.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return w.update(func(bucket *bolt.Bucket) error {
+		classes := bucket.Tx().Bucket([]byte(classesBucketName))
+		if classes == nil || classes.Get(key) == nil {
+			return ErrNotFound
+		}
+		return classes.Delete(key)
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// resetClasses forgets the classes registered by the test.
+func resetClasses(t *testing.T) {
+	t.Cleanup(func() {
+		registeredClasses.Lock()
+		registeredClasses.m = nil
+		registeredClasses.Unlock()
+	})
+}
+
+func TestParseClass(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Class
+	}{
+		{"", 0},
+		{"none", 0},
+		{"retryable", ClassRetryable},
+		{"Connection, timeout", ClassConnection | ClassTimeout},
+		{"nodata data", ClassNoData | ClassData},
+	} {
+		got, err := ParseClass(tc.in)
+		if err != nil {
+			t.Errorf("%q: %+v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: got %s, wanted %s", tc.in, got, tc.want)
+		}
+		if back, err := ParseClass(got.String()); err != nil || back != got {
+			t.Errorf("%q: round trip got %s, %v", got, back, err)
+		}
+	}
+	if _, err := ParseClass("retryable,fatal"); err == nil {
+		t.Error("unknown class: wanted error")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	resetClasses(t)
+	for _, tc := range []struct {
+		id   MsgID
+		want Class
+	}{
+		{MsgID{"ORA", 60}, ClassRetryable},
+		{MsgID{"ORA", 3113}, ClassConnection},
+		{MsgID{"TNS", 12541}, ClassConnection},
+		{MsgID{"ORA", 12170}, ClassConnection | ClassTimeout},
+		{MsgID{"ORA", 1}, ClassConstraint},
+		{MsgID{"ORA", 6512}, 0},
+	} {
+		if got := Classify(tc.id); got != tc.want {
+			t.Errorf("%s: got %s, wanted %s", tc.id, got, tc.want)
+		}
+	}
+
+	custom := MsgID{"ORA", 20001}
+	SetClass(custom, ClassTransient)
+	SetClass(MsgID{"ORA", 60}, 0)
+	if got := Classify(custom); got != ClassTransient {
+		t.Errorf("%s: got %s", custom, got)
+	}
+	if got := Classify(MsgID{"ORA", 60}); got != 0 {
+		t.Errorf("ORA-00060 unset: got %s", got)
+	}
+	classes := Classes()
+	if _, ok := classes[MsgID{"ORA", 60}]; ok {
+		t.Error("Classes: unset ORA-00060 listed")
+	}
+	if classes[custom] != ClassTransient {
+		t.Errorf("Classes: %s is %s", custom, classes[custom])
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	resetClasses(t)
+	deadlock := fmt.Errorf("update: %w", &oraErr{code: 60, message: "ORA-00060: deadlock detected while waiting for resource"})
+	lost := errors.New("ping: ORA-03113: end-of-file on communication channel")
+	unique := &oraErr{code: 1, message: "ORA-00001: unique constraint (SCOTT.PK_EMP) violated"}
+	for _, tc := range []struct {
+		err                         error
+		retryable, conn, constraint bool
+	}{
+		{nil, false, false, false},
+		{errors.New("EOF"), false, false, false},
+		{deadlock, true, false, false},
+		{lost, false, true, false},
+		{unique, false, false, true},
+	} {
+		if got := IsRetryable(tc.err); got != tc.retryable {
+			t.Errorf("IsRetryable(%v): got %t", tc.err, got)
+		}
+		if got := IsConnectionError(tc.err); got != tc.conn {
+			t.Errorf("IsConnectionError(%v): got %t", tc.err, got)
+		}
+		if got := IsConstraintViolation(tc.err); got != tc.constraint {
+			t.Errorf("IsConstraintViolation(%v): got %t", tc.err, got)
+		}
+	}
+}
+
+func TestStoredClasses(t *testing.T) {
+	resetClasses(t)
+	dbPath := newTestDB(t, testMsgs)
+	w, err := OpenWriter(dbPath, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetClass(MsgID{"ORA", 1600}, ClassData); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetClass(MsgID{"ORA", 1555}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetClass(MsgID{"ORA", 1}, ClassData); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.DeleteClass(MsgID{"ORA", 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.DeleteClass(MsgID{"ORA", 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteClass twice: got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if got := ClassifyIn(db, MsgID{"ORA", 1600}); got != ClassData {
+		t.Errorf("ORA-01600: got %s", got)
+	}
+	if got := ClassifyIn(db, MsgID{"ORA", 1555}); got != 0 {
+		t.Errorf("ORA-01555: got %s", got)
+	}
+	if got := ClassifyIn(db, MsgID{"ORA", 1}); got != ClassConstraint {
+		t.Errorf("ORA-00001: got %s", got)
+	}
+	if err := LoadClasses(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := Classify(MsgID{"ORA", 1555}); got != 0 {
+		t.Errorf("loaded ORA-01555: got %s", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"sync"
+)
+
+// ErrNoEmbedded is returned by Embedded when the binary is built without the oerr_embed tag.
+var ErrNoEmbedded = errors.New("no embedded catalog (build with -tags oerr_embed)")
+
+var embedded struct {
+	once sync.Once
+	db   *MemDB
+	err  error
+}
+
+// Embedded returns the catalog compiled into the binary.
+func Embedded() (*MemDB, error) {
+	embedded.once.Do(func() {
+		if len(embeddedCatalog) == 0 {
+			embedded.err = ErrNoEmbedded
+			return
+		}
+		embedded.db, embedded.err = ReadCatalog(bytes.NewReader(embeddedCatalog))
+	})
+	return embedded.db, embedded.err
+}
+
+// OpenOrEmbedded opens the DB (or the Layered DBs of the search path) by OpenURL,
+// falling back to the embedded catalog if none of the files exist.
+func OpenOrEmbedded(searchPath string, opts Options) (DB, error) {
+	var db DB
+	var err error
+	if len(SplitSearchPath(searchPath)) > 1 {
+		db, err = OpenLayered(searchPath, opts)
+	} else {
+		db, err = OpenURL(searchPath, opts)
+	}
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		if edb, eErr := Embedded(); eErr == nil {
+			return edb, nil
+		}
+	}
+	return db, err
+}
+
+// DefaultPath returns the search path of the default DB:
+// $OERR_DB, $BRUNO_HOME/data/ws/oerr.db or oerr.db.
+func DefaultPath() string {
+	if s := os.Getenv("OERR_DB"); s != "" {
+		return s
+	}
+	if os.Getenv("BRUNO_HOME") != "" {
+		return os.ExpandEnv("$BRUNO_HOME/data/ws/oerr.db")
+	}
+	return "oerr.db"
+}
+
+var defaultDB struct {
+	once sync.Once
+	db   DB
+	err  error
+}
+
+// Default returns the DB at DefaultPath, or the embedded catalog, opened once.
+func Default() (DB, error) {
+	defaultDB.once.Do(func() {
+		defaultDB.db, defaultDB.err = OpenOrEmbedded(DefaultPath(), DefaultOptions)
+	})
+	return defaultDB.db, defaultDB.err
+}
+
+// Lookup the message in the Default DB.
+func Lookup(id MsgID) (MsgData, error) {
+	db, err := Default()
+	if err != nil {
+		return MsgData{}, err
+	}
+	return db.Get(id)
+}
@@ -17,14 +17,14 @@ package oerr
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/yhat/scrape"
 	"go4.org/syncutil"
 	"golang.org/x/net/context"
@@ -38,57 +38,49 @@ var URL = `http://docs.oracle.com/cd/B28359_01/server.111/b28278/toc.htm`
 
 const bucketName = "oerr"
 
-// DownloadInto fills the DB by downloading the messages.
-func DownloadInto(dbPath, tocURL string) error {
-	os.Remove(dbPath)
-	db, err := bolt.Open(dbPath, 0664, nil)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
+// DownloadInto fills the DB (path or URL, see OpenURL) by downloading the messages.
+// The overrides and notes of a Bolt DB are kept.
+// If the download fails, the DB is not changed.
+func DownloadInto(dbURL, tocURL string) error {
 	msgCh := make(chan Message, 8)
+	dlDone := make(chan struct{})
+	var dlErr error
 	go func() {
-		db.NoSync = true
-		defer db.Sync()
-		if err := db.Update(func(tx *bolt.Tx) error {
-			bucket, err := tx.CreateBucketIfNotExists([]byte(bucketName))
-			if err != nil {
-				return err
-			}
+		dlErr = Download(context.Background(), msgCh, tocURL)
+		close(dlDone)
+	}()
+	wait := func() error { <-dlDone; return dlErr }
 
-			for msg := range msgCh {
-				key, err := msg.MsgID.MarshalBinary()
-				if err != nil {
-					log.Printf("cannot marshal %#v: %v", msg.MsgID, err)
-					continue
-				}
-				val, err := msg.MsgData.MarshalBinary()
-				if err != nil {
-					log.Printf("cannot marshal %#v: %v", msg.MsgData, err)
-					continue
-				}
-				if err := bucket.Put(key, val); err != nil {
-					log.Printf("Put(%#v): %v", msg, err)
-					return err
-				}
+	meta := map[string]string{
+		MetaURL:     tocURL,
+		MetaCreated: time.Now().UTC().Format(time.RFC3339),
+	}
+	err := ReplaceURL(dbURL, meta, func(yield func(Message, error) bool) {
+		for msg := range msgCh {
+			if !yield(msg, nil) {
+				return
 			}
-			return nil
-		}); err != nil {
-			log.Printf("Update: %v", err)
 		}
-	}()
-
-	if err := Download(context.Background(), msgCh, tocURL); err != nil {
-		return err
+		// the error rolls back the replacement
+		if err := wait(); err != nil {
+			yield(Message{}, err)
+		}
+	})
+	for range msgCh { // unblock Download
 	}
-	return nil
+	if dErr := wait(); dErr != nil {
+		return dErr
+	}
+	return err
 }
 
 // Download into the given channel, from the given URL.
 func Download(ctx context.Context, out chan<- Message, tocURL string) error {
 	defer func() { close(out) }()
-	body, err := dl(ctx, URL)
+	if tocURL == "" {
+		tocURL = URL
+	}
+	body, err := dl(ctx, tocURL)
 	if err != nil {
 		return err
 	}
@@ -98,7 +90,7 @@ func Download(ctx context.Context, out chan<- Message, tocURL string) error {
 		return err
 	}
 
-	baseURL := URL[:strings.LastIndex(URL, "/")]
+	baseURL := tocURL[:strings.LastIndex(tocURL, "/")]
 	gate := syncutil.NewGate(8)
 	var grp syncutil.Group
 	for _, lnk := range links {
@@ -214,6 +206,10 @@ func dl(ctx context.Context, URL string) (io.ReadCloser, error) {
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", URL, resp.Status)
+	}
 	r, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
 	if err != nil {
 		resp.Body.Close()
@@ -16,6 +16,10 @@
 package oerr
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -242,3 +246,55 @@ func TestParse(t *testing.T) {
 		t.Errorf("Parsed only %d!", n)
 	}
 }
+
+func TestDownloadInto(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok/toc.htm":
+			io.WriteString(w, `<a href="e0.htm">ORA-00000</a>`)
+		case "/bad/toc.htm":
+			io.WriteString(w, `<a href="e0.htm">ORA-00000</a> <a href="e900.htm">ORA-00900</a>`)
+		case "/ok/e0.htm", "/bad/e0.htm":
+			io.WriteString(w, e0)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	for _, dbURL := range []string{filepath.Join(dir, "oerr.db"), "pack://" + filepath.Join(dir, "oerr.pack")} {
+		if err := ReplaceURL(dbURL, map[string]string{MetaURL: "old"}, seqOf(testMsgs...)); err != nil {
+			t.Fatal(err)
+		}
+		check := func(what, wantURL string, wantCount int) {
+			t.Helper()
+			db, err := OpenURL(dbURL, DefaultOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			var n int
+			for _, err := range db.All() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				n++
+			}
+			if meta := db.Meta(); n != wantCount || meta[MetaURL] != wantURL {
+				t.Errorf("%s %s: got %d messages from %q, wanted %d from %q", dbURL, what, n, meta[MetaURL], wantCount, wantURL)
+			}
+		}
+
+		for _, path := range []string{"/missing/toc.htm", "/bad/toc.htm"} {
+			if err := DownloadInto(dbURL, srv.URL+path); err == nil {
+				t.Errorf("%s %s: wanted error", dbURL, path)
+			}
+			check(path, "old", len(testMsgs))
+		}
+		if err := DownloadInto(dbURL, srv.URL+"/ok/toc.htm"); err != nil {
+			t.Fatalf("%s: %+v", dbURL, err)
+		}
+		check("ok", srv.URL+"/ok/toc.htm", 7)
+	}
+}
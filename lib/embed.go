@@ -0,0 +1,31 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+//go:build oerr_embed
+
+package oerr
+
+import _ "embed"
+
+//go:generate sh -c "cd .. && go run . download oerr.db && go run . dump lib/catalog.gz"
+
+// embeddedCatalog is written by "oerr dump", see WriteCatalog.
+//
+// The committed catalog.gz is a small seed of the most common messages,
+// so a clean checkout builds with the oerr_embed tag;
+// "go generate -tags oerr_embed ./lib" replaces it with the full, downloaded catalog.
+//
+//go:embed catalog.gz
+var embeddedCatalog []byte
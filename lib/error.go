@@ -0,0 +1,143 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Error is an Oracle error: the code, the runtime message and the wrapped error.
+type Error struct {
+	ID MsgID
+	// Text is the runtime message, without the code, such as
+	// "unique constraint (SCOTT.PK_EMP) violated".
+	Text string
+	// Err is the original error, if any.
+	Err error
+	// DB resolves the MsgData, the Default DB if nil.
+	DB interface {
+		Get(MsgID) (MsgData, error)
+	}
+
+	once    sync.Once
+	data    MsgData
+	dataErr error
+}
+
+// NewError returns an Error of the id, to be used as a sentinel:
+//
+//	var ErrUniqueConstraint = oerr.NewError(oerr.MsgID{Prefix: "ORA", Code: 1})
+//	...
+//	if errors.Is(oerr.FromError(err), ErrUniqueConstraint) {
+func NewError(id MsgID) *Error { return &Error{ID: id} }
+
+// Error returns the code and the runtime message, such as "ORA-00001: unique constraint (SCOTT.PK_EMP) violated".
+func (e *Error) Error() string {
+	if e.Text == "" {
+		return e.ID.String()
+	}
+	return e.ID.String() + ": " + e.Text
+}
+
+// Unwrap returns the original error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is reports whether the target is an *Error with the same ID,
+// or has the same code by its Prefix() string and Number() int methods
+// (as the Code type generated by oerr gen go).
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case *Error:
+		return t.ID == e.ID
+	case interface {
+		Prefix() string
+		Number() int
+	}:
+		return t.Prefix() == e.ID.Prefix && t.Number() == int(e.ID.Code)
+	}
+	return false
+}
+
+// Data returns the MsgData of the ID, looked up in the DB at the first call.
+func (e *Error) Data() (MsgData, error) {
+	e.once.Do(func() {
+		if e.DB != nil {
+			e.data, e.dataErr = e.DB.Get(e.ID)
+		} else {
+			e.data, e.dataErr = Lookup(e.ID)
+		}
+	})
+	return e.data, e.dataErr
+}
+
+// Args returns the values of the placeholders of the description in the runtime message,
+// see Template.Match.
+func (e *Error) Args() ([]string, bool) {
+	data, err := e.Data()
+	if err != nil {
+		return nil, false
+	}
+	return data.Match(e.Text)
+}
+
+var anyScanner = NewScanner()
+
+// FromError returns the Oracle error in err:
+//
+//   - a copy of the *Error in its chain, so a sentinel is not modified through it,
+//   - the error in its chain with a Code() int method (such as godror's *OraErr), as an ORA error,
+//   - or the first code found in the error text,
+//
+// or nil if there is none.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var oe *Error
+	if errors.As(err, &oe) {
+		return &Error{ID: oe.ID, Text: oe.Text, Err: oe.Err, DB: oe.DB}
+	}
+	var coder interface{ Code() int }
+	if errors.As(err, &coder) && coder.Code() != 0 {
+		code := coder.Code()
+		if code < 0 {
+			code = -code
+		}
+		oe = &Error{ID: MsgID{Prefix: "ORA", Code: uint32(code)}, Err: err}
+		text := coder.(error).Error()
+		if m, ok := coder.(interface{ Message() string }); ok {
+			text = m.Message()
+		}
+		oe.Text = textOf(oe.ID, text)
+		return oe
+	}
+	if occs := anyScanner.FindAll(err.Error()); len(occs) != 0 {
+		return &Error{ID: occs[0].ID, Text: occs[0].Text, Err: err}
+	}
+	return nil
+}
+
+// textOf returns the text of the first occurrence of id in s, or s without its code prefix.
+func textOf(id MsgID, s string) string {
+	for _, occ := range anyScanner.FindAll(s) {
+		if occ.ID == id {
+			return occ.Text
+		}
+	}
+	return strings.TrimSpace(rMsgIDPrefix.ReplaceAllString(s, ""))
+}
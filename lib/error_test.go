@@ -0,0 +1,91 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// oraErr mimics godror's *OraErr.
+type oraErr struct {
+	code    int
+	message string
+}
+
+func (e *oraErr) Error() string   { return e.message }
+func (e *oraErr) Code() int       { return e.code }
+func (e *oraErr) Message() string { return e.message }
+
+// code mimics the Code generated by oerr gen go.
+type code uint32
+
+func (c code) Error() string  { return fmt.Sprintf("ORA-%05d", c) }
+func (c code) Prefix() string { return "ORA" }
+func (c code) Number() int    { return int(c) }
+
+func TestFromError(t *testing.T) {
+	errUnique := NewError(MsgID{"ORA", 1})
+	mdb, err := NewMemDB(nil, seqOf(testMsgs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{nil, "<nil>"},
+		{io.EOF, "<nil>"},
+		{fmt.Errorf("insert: %w", &oraErr{code: 1, message: "ORA-00001: unique constraint (SCOTT.PK_EMP) violated"}),
+			"ORA-00001: unique constraint (SCOTT.PK_EMP) violated"},
+		{fmt.Errorf("wrapped: %w", errUnique), "ORA-00001"},
+		{errors.New("exec: ORA-00060: deadlock detected while waiting for resource\nORA-06512: at line 1"),
+			"ORA-00060: deadlock detected while waiting for resource"},
+		{errors.New("dial: TNS-12154: TNS:could not resolve the connect identifier specified"),
+			"TNS-12154: TNS:could not resolve the connect identifier specified"},
+	} {
+		oe := FromError(tc.err)
+		if got := fmt.Sprint(oe); oe == nil && tc.want != "<nil>" || oe != nil && got != tc.want {
+			t.Errorf("%v: got %q, wanted %q", tc.err, got, tc.want)
+		}
+		if oe == nil {
+			continue
+		}
+		if oe.Err != tc.err && !errors.Is(tc.err, oe) {
+			t.Errorf("%v: does not wrap the original", tc.err)
+		}
+		if oe == errUnique {
+			t.Errorf("%v: returned the sentinel", tc.err)
+		}
+		if oe.ID.Code == 1 {
+			if !errors.Is(oe, errUnique) || !errors.Is(oe, code(1)) || errors.Is(oe, code(60)) {
+				t.Errorf("%v: Is does not match", oe)
+			}
+			oe.DB = mdb
+			if data, err := oe.Data(); err != nil || data != testMsgs[0].MsgData {
+				t.Errorf("%v: Data: got %v, %v", oe, data, err)
+			}
+			if args, ok := oe.Args(); oe.Text != "" && (!ok || fmt.Sprint(args) != "[SCOTT PK_EMP]") {
+				t.Errorf("%v: Args: got %q, %t", oe, args, ok)
+			}
+		}
+	}
+	if errUnique.DB != nil {
+		t.Error("the sentinel is modified")
+	}
+}
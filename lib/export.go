@@ -0,0 +1,334 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Provenance tells where a message comes from.
+type Provenance struct {
+	// Layer is the name of the Layered DB's layer.
+	Layer string
+	// Source is the URL the catalog was downloaded from (MetaURL).
+	Source string
+	// Created is the build time of the catalog (MetaCreated).
+	Created string
+	// Override is true for custom and patched messages (see Writer).
+	Override bool
+}
+
+// Provenancer is implemented by the DBs knowing more about
+// their messages' provenance than their metadata.
+type Provenancer interface {
+	Provenance(MsgID) Provenance
+}
+
+// GetProvenance returns the provenance of the message in the DB.
+func GetProvenance(db DB, id MsgID) Provenance {
+	if p, ok := db.(Provenancer); ok {
+		return p.Provenance(id)
+	}
+	meta := db.Meta()
+	return Provenance{Source: meta[MetaURL], Created: meta[MetaCreated]}
+}
+
+func (db *dbS) Provenance(id MsgID) Provenance {
+	meta := db.Meta()
+	p := Provenance{Source: meta[MetaURL], Created: meta[MetaCreated]}
+	if key, err := id.MarshalBinary(); err == nil {
+		db.view(func(tx *bolt.Tx) error {
+			if bucket := tx.Bucket([]byte(overridesBucketName)); bucket != nil {
+				p.Override = bucket.Get(key) != nil
+			}
+			return nil
+		})
+	}
+	return p
+}
+
+// Provenances returns the provenance lookup of the messages of the DB,
+// reading its metadata and overrides once, for exporting many messages.
+func Provenances(db DB) (func(MsgID) Provenance, error) {
+	switch db := db.(type) {
+	case *dbS:
+		return db.provenances()
+	case *ReloadingDB:
+		g, err := db.acquire()
+		if err != nil {
+			return nil, err
+		}
+		defer g.inFlight.Done()
+		return g.provenances()
+	case *Layered:
+		fs := make([]func(MsgID) Provenance, len(db.Layers))
+		for i, layer := range db.Layers {
+			var err error
+			if fs[i], err = Provenances(layer.DB); err != nil {
+				return nil, err
+			}
+		}
+		return func(id MsgID) Provenance {
+			for i, layer := range db.Layers {
+				if _, err := layer.DB.Get(id); err == nil {
+					p := fs[i](id)
+					p.Layer = layer.Name
+					return p
+				}
+			}
+			return Provenance{}
+		}, nil
+	}
+	return func(id MsgID) Provenance { return GetProvenance(db, id) }, nil
+}
+
+// provenances reads the metadata and the keys of the overrides in one transaction.
+func (db *dbS) provenances() (func(MsgID) Provenance, error) {
+	var source, created string
+	overrides := make(map[MsgID]bool)
+	if err := db.view(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(metaBucketName)); bucket != nil {
+			source, created = string(bucket.Get([]byte(MetaURL))), string(bucket.Get([]byte(MetaCreated)))
+		}
+		bucket := tx.Bucket([]byte(overridesBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, _ []byte) error {
+			var id MsgID
+			if err := id.UnmarshalBinary(k); err != nil {
+				return err
+			}
+			overrides[id] = true
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return func(id MsgID) Provenance {
+		return Provenance{Source: source, Created: created, Override: overrides[id]}
+	}, nil
+}
+
+func (l *Layered) Provenance(id MsgID) Provenance {
+	for _, layer := range l.Layers {
+		if _, err := layer.DB.Get(id); err == nil {
+			p := GetProvenance(layer.DB, id)
+			p.Layer = layer.Name
+			return p
+		}
+	}
+	return Provenance{}
+}
+
+// Record is the exported form of a message.
+type Record struct {
+	ID          string `json:"id"`
+	Prefix      string `json:"prefix"`
+	Code        uint32 `json:"code"`
+	Description string `json:"description"`
+	Cause       string `json:"cause,omitempty"`
+	Action      string `json:"action,omitempty"`
+	Layer       string `json:"layer,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Created     string `json:"created,omitempty"`
+	Override    bool   `json:"override,omitempty"`
+}
+
+// NewRecord returns the Record of the message.
+func NewRecord(msg Message, p Provenance) Record {
+	return Record{
+		ID: msg.MsgID.String(), Prefix: msg.Prefix, Code: msg.Code,
+		Description: msg.Description, Cause: msg.Cause, Action: msg.Action,
+		Layer: p.Layer, Source: p.Source, Created: p.Created, Override: p.Override,
+	}
+}
+
+// Message returns the message of the record.
+func (r Record) Message() Message {
+	return Message{
+		MsgID:   MsgID{Prefix: r.Prefix, Code: r.Code},
+		MsgData: MsgData{Description: r.Description, Cause: r.Cause, Action: r.Action},
+	}
+}
+
+var recordFields = []string{"id", "prefix", "code", "description", "cause", "action", "layer", "source", "created", "override"}
+
+func (r Record) fields() []string {
+	var override string
+	if r.Override {
+		override = "true"
+	}
+	return []string{r.ID, r.Prefix, strconv.FormatUint(uint64(r.Code), 10),
+		r.Description, r.Cause, r.Action, r.Layer, r.Source, r.Created, override}
+}
+
+// Formats of the Encoder.
+var Formats = []string{"json", "jsonl", "csv", "yaml"}
+
+// Encoder writes records.
+type Encoder interface {
+	Encode(Record) error
+	// Close finishes the output, but does not close the underlying writer.
+	Close() error
+}
+
+// NewEncoder returns an Encoder of the format (see Formats).
+func NewEncoder(w io.Writer, format string) (Encoder, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case "json":
+		return &jsonEncoder{w: bw, array: true}, nil
+	case "jsonl":
+		return &jsonEncoder{w: bw}, nil
+	case "csv":
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case "yaml":
+		return &yamlEncoder{w: bw}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (known: %q)", format, Formats)
+}
+
+// Export the messages of the DB, with their provenance, in the format.
+func Export(w io.Writer, format string, db DB, msgs iter.Seq2[Message, error]) error {
+	provenance, err := Provenances(db)
+	if err != nil {
+		return err
+	}
+	enc, err := NewEncoder(w, format)
+	if err != nil {
+		return err
+	}
+	for msg, err := range msgs {
+		if err != nil {
+			return err
+		}
+		if err = enc.Encode(NewRecord(msg, provenance(msg.MsgID))); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+type jsonEncoder struct {
+	w     *bufio.Writer
+	array bool
+	n     int
+}
+
+func (e *jsonEncoder) Encode(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if e.array {
+		if e.n == 0 {
+			e.w.WriteString("[\n")
+		} else {
+			e.w.WriteString(",\n")
+		}
+	}
+	e.n++
+	e.w.Write(b)
+	if !e.array {
+		e.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e *jsonEncoder) Close() error {
+	if e.array {
+		if e.n == 0 {
+			e.w.WriteString("[")
+		}
+		e.w.WriteString("\n]\n")
+	}
+	return e.w.Flush()
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (e *csvEncoder) Encode(r Record) error {
+	if !e.header {
+		e.header = true
+		if err := e.w.Write(recordFields); err != nil {
+			return err
+		}
+	}
+	return e.w.Write(r.fields())
+}
+
+func (e *csvEncoder) Close() error {
+	if !e.header {
+		e.header = true
+		e.w.Write(recordFields)
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// yamlEncoder writes a sequence of mappings, the strings double-quoted
+// (a JSON string is a valid YAML double-quoted scalar).
+type yamlEncoder struct {
+	w *bufio.Writer
+	n int
+}
+
+func (e *yamlEncoder) Encode(r Record) error {
+	e.n++
+	for i, v := range r.fields() {
+		if v == "" {
+			continue
+		}
+		if i == 0 {
+			e.w.WriteString("- ")
+		} else {
+			e.w.WriteString("  ")
+		}
+		e.w.WriteString(recordFields[i])
+		e.w.WriteString(": ")
+		switch recordFields[i] {
+		case "code", "override":
+			e.w.WriteString(v)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			e.w.Write(b)
+		}
+		e.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e *yamlEncoder) Close() error {
+	if e.n == 0 {
+		e.w.WriteString("[]\n")
+	}
+	return e.w.Flush()
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	dbPath := newTestDB(t, testMsgs)
+	w, err := OpenWriter(dbPath, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Put(Message{MsgID{"ORA", 20001}, MsgData{Description: "order \"x\" not found"}}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	provenance, err := Provenances(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for msg, err := range db.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := provenance(msg.MsgID), GetProvenance(db, msg.MsgID); got != want {
+			t.Errorf("%s: got %+v, wanted %+v", msg.MsgID, got, want)
+		}
+	}
+
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		if err := Export(&buf, format, db, db.Prefix("ORA")); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		out := buf.String()
+		switch format {
+		case "json":
+			var recs []Record
+			if err := json.Unmarshal(buf.Bytes(), &recs); err != nil {
+				t.Fatalf("json: %v\n%s", err, out)
+			}
+			if len(recs) != 6 || recs[5].ID != "ORA-20001" || !recs[5].Override || recs[0].Override {
+				t.Errorf("json: got %+v", recs)
+			}
+		case "jsonl":
+			if n := strings.Count(out, "\n"); n != 6 {
+				t.Errorf("jsonl: got %d lines", n)
+			}
+		case "csv":
+			if !strings.HasPrefix(out, "id,prefix,code,description,") || !strings.Contains(out, `ORA-20001,ORA,20001,"order ""x"" not found",,,,,,true`) {
+				t.Errorf("csv: got\n%s", out)
+			}
+		case "yaml":
+			if !strings.Contains(out, "- id: \"ORA-20001\"\n  prefix: \"ORA\"\n  code: 20001\n  description: \"order \\\"x\\\" not found\"\n  override: true\n") {
+				t.Errorf("yaml: got\n%s", out)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// FollowOptions for FollowFile.
+type FollowOptions struct {
+	// Interval of checking the file for new data, and for rotation. Zero means 250ms.
+	Interval time.Duration
+	// FromEnd starts at the end of the file, as tail -f does, not at its start.
+	FromEnd bool
+	// OnRotate is called when the file is reopened after a rotation,
+	// or rewound after a truncation.
+	OnRotate func(path string)
+}
+
+// Follower reads a file as tail -F does: at its end, waits for more data,
+// reopening the file when it is replaced (rotated), rewinding it when it is truncated.
+type Follower struct {
+	ctx  context.Context
+	path string
+	opts FollowOptions
+	fh   *os.File
+	fi   os.FileInfo
+	off  int64
+}
+
+var _ io.ReadCloser = (*Follower)(nil)
+
+// FollowFile opens the file for following; the reads return ctx.Err() after ctx is done.
+func FollowFile(ctx context.Context, path string, opts FollowOptions) (*Follower, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = 250 * time.Millisecond
+	}
+	f := &Follower{ctx: ctx, path: path, opts: opts}
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if f.fi, err = fh.Stat(); err != nil {
+		fh.Close()
+		return nil, err
+	}
+	f.fh = fh
+	if opts.FromEnd {
+		if f.off, err = fh.Seek(0, io.SeekEnd); err != nil {
+			fh.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Read reads from the file, waiting for data at its end.
+func (f *Follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.fh.Read(p)
+		f.off += int64(n)
+		if n != 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+		// at the end
+		if reopened, err := f.checkRotation(); err != nil {
+			return 0, err
+		} else if reopened {
+			continue
+		}
+		t := time.NewTimer(f.opts.Interval)
+		select {
+		case <-f.ctx.Done():
+			t.Stop()
+			return 0, f.ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// checkRotation reopens the file if it is replaced, or rewinds it if it is truncated,
+// and returns whether anything has to be read.
+func (f *Follower) checkRotation() (bool, error) {
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		// being rotated: keep the old one till the new appears
+		return false, nil
+	}
+	if !os.SameFile(f.fi, fi) {
+		fh, err := os.Open(f.path)
+		if err != nil {
+			return false, nil
+		}
+		// read the rest of the old file first
+		if cur, err := f.fh.Stat(); err == nil && cur.Size() > f.off {
+			fh.Close()
+			return true, nil
+		}
+		f.fh.Close()
+		f.fh, f.fi, f.off = fh, fi, 0
+		if f.opts.OnRotate != nil {
+			f.opts.OnRotate(f.path)
+		}
+		return true, nil
+	}
+	if fi.Size() < f.off {
+		if _, err := f.fh.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		f.off = 0
+		if f.opts.OnRotate != nil {
+			f.opts.OnRotate(f.path)
+		}
+		return true, nil
+	}
+	return fi.Size() > f.off, nil
+}
+
+// Close the file.
+func (f *Follower) Close() error { return f.fh.Close() }
@@ -0,0 +1,113 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFollowFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	write := func(flag int, text string) {
+		fh, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fh.Close()
+		if _, err := fh.WriteString(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(os.O_TRUNC, "old\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	rotated := make(chan string, 2)
+	f, err := FollowFile(ctx, path, FollowOptions{Interval: 10 * time.Millisecond, FromEnd: true,
+		OnRotate: func(path string) { rotated <- path }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// read in the background, to notice the truncation
+	lines, errc := make(chan string), make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(f)
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				errc <- err
+				return
+			}
+			lines <- line
+		}
+	}()
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case err := <-errc:
+			t.Fatalf("read: %+v", err)
+		}
+		return ""
+	}
+	waitRotate := func(what string) {
+		select {
+		case <-rotated:
+		case <-ctx.Done():
+			t.Fatalf("%s: no rotation", what)
+		}
+	}
+
+	write(os.O_APPEND, "first\n")
+	if got := next(); got != "first\n" {
+		t.Errorf("append: got %q", got)
+	}
+	// rotate: the rest of the old file is read first
+	write(os.O_APPEND, "last of old\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	write(os.O_TRUNC, "new\n")
+	if got := next(); got != "last of old\n" {
+		t.Errorf("rotate: got %q", got)
+	}
+	if got := next(); got != "new\n" {
+		t.Errorf("rotated: got %q", got)
+	}
+	waitRotate("rename")
+	// copytruncate
+	write(os.O_TRUNC, "")
+	waitRotate("truncate")
+	write(os.O_APPEND, "truncated\n")
+	if got := next(); got != "truncated\n" {
+		t.Errorf("truncate: got %q", got)
+	}
+	if n := len(rotated); n != 0 {
+		t.Errorf("got %d more rotations", n)
+	}
+
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled: got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"iter"
+	"regexp"
+	"strings"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+// GoOptions of the Go code generation.
+type GoOptions struct {
+	// Package is the name of the generated package, by default oraerr.
+	Package string
+	// Header is the comment on the top of the generated file.
+	Header string
+}
+
+// Go writes a Go package with a Code constant for each message, such as
+//
+//	ORA00001UniqueConstraint Code = 1
+//
+// The ORA codes equal the numbers, so godror's errors can be compared as
+// Code(oraErr.Code()) == oraerr.ORA00001UniqueConstraint.
+// Code implements error, so the constants are sentinel errors, too,
+// and Wrap makes errors with a Code() int method match them with errors.Is.
+func Go(w io.Writer, opts GoOptions, msgs iter.Seq2[oerr.Message, error]) error {
+	if opts.Package == "" {
+		opts.Package = "oraerr"
+	}
+	if !token.IsIdentifier(opts.Package) {
+		return fmt.Errorf("%q is not a Go package name", opts.Package)
+	}
+	var consts, descs bytes.Buffer
+	for msg, err := range msgs {
+		if err != nil {
+			return err
+		}
+		code, err := goCode(msg.MsgID)
+		if err != nil {
+			return err
+		}
+		name := goName(msg)
+		verb := "%#x"
+		if msg.Prefix == "ORA" {
+			verb = "%d"
+		}
+		fmt.Fprintf(&consts, "\t// %s: %s\n\t%s Code = "+verb+"\n",
+			msg.MsgID, oneLine(msg.Description), name, code)
+		fmt.Fprintf(&descs, "\t%s: %q,\n", name, msg.Description)
+	}
+
+	var buf bytes.Buffer
+	for _, line := range strings.Split(opts.Header, "\n") {
+		if line != "" {
+			fmt.Fprintf(&buf, "// %s\n", line)
+		}
+	}
+	fmt.Fprintf(&buf, goTemplate, opts.Package, consts.Bytes(), descs.Bytes())
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format %s: %w", buf.Bytes(), err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// goCode packs the prefix (A-Z as 1-26 on 5 bits each, ORA as 0) above the 17 bits of the number.
+func goCode(id oerr.MsgID) (uint32, error) {
+	if id.Code > 1<<17-1 {
+		return 0, fmt.Errorf("%s: code is too big", id)
+	}
+	if id.Prefix == "ORA" {
+		return id.Code, nil
+	}
+	if len(id.Prefix) != 3 {
+		return 0, fmt.Errorf("%s: prefix is not three letters", id)
+	}
+	var p uint32
+	for _, c := range []byte(id.Prefix) {
+		if c < 'A' || 'Z' < c {
+			return 0, fmt.Errorf("%s: prefix is not three uppercase letters", id)
+		}
+		p = p<<5 | uint32(c-'A'+1)
+	}
+	return p<<17 | id.Code, nil
+}
+
+// goStopWords end the name taken from the description.
+var goStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "for": true, "to": true, "in": true,
+	"on": true, "at": true, "by": true, "with": true, "while": true, "is": true, "was": true,
+	"string": true, "number": true,
+}
+
+var rGoToken = regexp.MustCompile(`[A-Za-z0-9-]+|[^\sA-Za-z0-9-]`)
+
+// goName returns the constant name of the message: the ID without the dash,
+// and at most three words of the description till the first stop word or punctuation.
+func goName(msg oerr.Message) string {
+	name := fmt.Sprintf("%s%05d", msg.Prefix, msg.Code)
+	var n int
+	for _, tok := range rGoToken.FindAllString(strings.TrimPrefix(msg.Description, msg.Prefix+":"), -1) {
+		if word := strings.Trim(tok, "-"); word == "" || !isAlnum(word[0]) || goStopWords[strings.ToLower(word)] {
+			if n == 0 {
+				continue
+			}
+			break
+		}
+		for _, part := range strings.Split(tok, "-") {
+			if part != "" {
+				name += strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+			}
+		}
+		if n++; n == 3 {
+			break
+		}
+	}
+	return name
+}
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+const goTemplate = `// Code generated by oerr gen go. DO NOT EDIT.
+
+// Package %[1]s contains the Oracle error codes.
+package %[1]s
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code is an error code: the number for ORA, and the prefix encoded above it for the others.
+//
+// Code implements error, so the constants can be used as sentinel errors,
+// see Wrap.
+type Code uint32
+
+const (
+%[2]s)
+
+var descriptions = map[Code]string{
+%[3]s}
+
+// Prefix of the code, such as ORA.
+func (c Code) Prefix() string {
+	p := uint32(c) >> 17
+	if p == 0 {
+		return "ORA"
+	}
+	return string([]byte{byte(p>>10&31) + 'A' - 1, byte(p>>5&31) + 'A' - 1, byte(p&31) + 'A' - 1})
+}
+
+// Number of the code, without the prefix.
+func (c Code) Number() int { return int(c & (1<<17 - 1)) }
+
+// String returns the ID, such as ORA-00001.
+func (c Code) String() string { return fmt.Sprintf("%%s-%%05d", c.Prefix(), c.Number()) }
+
+// Description of the message.
+func (c Code) Description() string { return descriptions[c] }
+
+// Error returns the ID and the description.
+func (c Code) Error() string {
+	if d := c.Description(); d != "" {
+		return c.String() + ": " + d
+	}
+	return c.String()
+}
+
+// CodeOf returns the ORA code of the error (the first in the chain
+// with a Code() int method, such as godror's), or the Code in the chain.
+func CodeOf(err error) (Code, bool) {
+	var c Code
+	if errors.As(err, &c) {
+		return c, true
+	}
+	var coder interface{ Code() int }
+	if errors.As(err, &coder) && coder.Code() != 0 {
+		n := coder.Code()
+		if n < 0 {
+			n = -n
+		}
+		return Code(n), true
+	}
+	return 0, false
+}
+
+// Wrap the error, to make errors.Is(Wrap(err), ORA00001UniqueConstraint) true for an ORA-00001.
+// Wrap returns nil for nil, and the error as is when CodeOf does not find a code.
+func Wrap(err error) error {
+	c, ok := CodeOf(err)
+	if !ok {
+		return err
+	}
+	return &codeError{code: c, err: err}
+}
+
+type codeError struct {
+	code Code
+	err  error
+}
+
+func (e *codeError) Error() string        { return e.err.Error() }
+func (e *codeError) Unwrap() error        { return e.err }
+func (e *codeError) Is(target error) bool { return target == e.code }
+`
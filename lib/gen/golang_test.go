@@ -0,0 +1,87 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+func TestGoName(t *testing.T) {
+	for _, tc := range []struct {
+		id   oerr.MsgID
+		desc string
+		want string
+	}{
+		{oerr.MsgID{Prefix: "ORA", Code: 1}, "unique constraint (string.string) violated", "ORA00001UniqueConstraint"},
+		{oerr.MsgID{Prefix: "ORA", Code: 60}, "deadlock detected while waiting for resource", "ORA00060DeadlockDetected"},
+		{oerr.MsgID{Prefix: "ORA", Code: 1555}, "snapshot too old: rollback segment number string with name \"string\" too small", "ORA01555SnapshotTooOld"},
+		{oerr.MsgID{Prefix: "ORA", Code: 3113}, "end-of-file on communication channel", "ORA03113EndOfFile"},
+		{oerr.MsgID{Prefix: "ORA", Code: 6512}, "at string line string", "ORA06512Line"},
+		{oerr.MsgID{Prefix: "TNS", Code: 12154}, "TNS:could not resolve the connect identifier specified", "TNS12154CouldNotResolve"},
+		{oerr.MsgID{Prefix: "ORA", Code: 20000}, "", "ORA20000"},
+	} {
+		if got := goName(oerr.Message{MsgID: tc.id, MsgData: oerr.MsgData{Description: tc.desc}}); got != tc.want {
+			t.Errorf("%s: got %q, wanted %q", tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestGo(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Go(&buf, GoOptions{Package: "oraerr", Header: "test"}, seqOf(testMsgs...)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("// test\n// Code generated by oerr gen go. DO NOT EDIT.\n")) {
+		t.Errorf("no header: %.100s", buf.Bytes())
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "oraerr.go", buf.Bytes(), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("oraerr", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, buf.Bytes())
+	}
+	for name, want := range map[string]string{
+		"ORA00001UniqueConstraint": "1",
+		"ORA00060DeadlockDetected": "60",
+		"PLS00201Identifier":       "2200305865", // (P=16, L=12, S=19)<<17 | 201
+	} {
+		obj, ok := pkg.Scope().Lookup(name).(*types.Const)
+		if !ok {
+			t.Errorf("%s: not found", name)
+		} else if got := obj.Val().String(); got != want {
+			t.Errorf("%s: got %s, wanted %s", name, got, want)
+		}
+	}
+	if !strings.Contains(buf.String(), `ORA00001UniqueConstraint: "unique constraint (string.string) violated",`) {
+		t.Error("no description")
+	}
+	if err := Go(&buf, GoOptions{Package: "ora-err"}, seqOf()); err == nil {
+		t.Error("wanted error for bad package name")
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// Package gen generates source code from the catalog.
+package gen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+// OracleOptions of the Oracle DDL, data and PL/SQL package generation.
+type OracleOptions struct {
+	// Table is the name of the table, by default OERR_MESSAGE.
+	Table string
+	// Package is the name of the PL/SQL package, by default OERR.
+	Package string
+	// Batch is the number of rows per INSERT ALL statement, by default 100.
+	Batch int
+	// Header is the comment on the top of the generated files.
+	Header string
+}
+
+var rIdent = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]{0,127}(\.[A-Za-z][A-Za-z0-9_$#]{0,127})?$`)
+
+func (opts OracleOptions) check() (OracleOptions, error) {
+	if opts.Table == "" {
+		opts.Table = "OERR_MESSAGE"
+	}
+	if opts.Package == "" {
+		opts.Package = "OERR"
+	}
+	if opts.Batch <= 0 {
+		opts.Batch = 100
+	}
+	for _, nm := range []string{opts.Table, opts.Package} {
+		if !rIdent.MatchString(nm) {
+			return opts, fmt.Errorf("%q is not an Oracle identifier", nm)
+		}
+	}
+	return opts, nil
+}
+
+func (opts OracleOptions) header(w io.Writer) {
+	for _, line := range strings.Split(opts.Header, "\n") {
+		if line != "" {
+			fmt.Fprintf(w, "-- %s\n", line)
+		}
+	}
+}
+
+// constraintName is the name of the table's primary key.
+func (opts OracleOptions) constraintName() string {
+	nm := opts.Table
+	if i := strings.LastIndexByte(nm, '.'); i >= 0 {
+		nm = nm[i+1:]
+	}
+	if len(nm) > 125 {
+		nm = nm[:125]
+	}
+	return nm + "_PK"
+}
+
+// OracleTable writes the CREATE TABLE script.
+func OracleTable(w io.Writer, opts OracleOptions) error {
+	opts, err := opts.check()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	opts.header(bw)
+	fmt.Fprintf(bw, `CREATE TABLE %s (
+  prefix VARCHAR2(3) NOT NULL,
+  code NUMBER(5) NOT NULL,
+  description VARCHAR2(4000) NOT NULL,
+  cause CLOB,
+  action CLOB,
+  CONSTRAINT %s PRIMARY KEY (prefix, code)
+);
+`, opts.Table, opts.constraintName())
+	return bw.Flush()
+}
+
+// OracleInserts writes the messages as batched INSERT ALL statements, for SQL*Plus.
+func OracleInserts(w io.Writer, opts OracleOptions, msgs iter.Seq2[oerr.Message, error]) error {
+	opts, err := opts.check()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	opts.header(bw)
+	bw.WriteString("SET DEFINE OFF\n")
+	var n int
+	for msg, err := range msgs {
+		if err != nil {
+			return err
+		}
+		if n%opts.Batch == 0 {
+			if n != 0 {
+				bw.WriteString("SELECT 1 FROM DUAL;\n")
+			}
+			bw.WriteString("INSERT ALL\n")
+		}
+		n++
+		fmt.Fprintf(bw, "  INTO %s (prefix, code, description, cause, action) VALUES (\n    %s, %d,\n    %s,\n    %s,\n    %s)\n",
+			opts.Table, oraString(msg.Prefix, false), msg.Code,
+			oraString(msg.Description, false), oraString(msg.Cause, true), oraString(msg.Action, true))
+	}
+	if n != 0 {
+		bw.WriteString("SELECT 1 FROM DUAL;\n")
+	}
+	bw.WriteString("COMMIT;\n")
+	return bw.Flush()
+}
+
+// oraLiteralMax is the length of the literal chunks, in bytes after escaping,
+// to stay in the 4000 bytes limit of the SQL string literals
+// and in the 2499 characters line limit of SQL*Plus.
+const oraLiteralMax = 1000
+
+// oraString returns the text as an SQL literal.
+// Each chunk of the text is on its own line, the chunks of CLOBs are wrapped in TO_CLOB,
+// and the line breaks are written as CHR(10), so no line of the text can end the statement.
+func oraString(s string, clob bool) string {
+	if s == "" {
+		return "NULL"
+	}
+	var parts []string
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		cr := strings.HasSuffix(line, "\r")
+		line = strings.TrimSuffix(line, "\r")
+		for line != "" {
+			var j, n int
+			for j < len(line) {
+				r, size := utf8.DecodeRuneInString(line[j:])
+				escaped := size
+				if r == '\'' {
+					escaped++
+				}
+				if n+escaped > oraLiteralMax {
+					break
+				}
+				n += escaped
+				j += size
+			}
+			lit := "'" + strings.ReplaceAll(line[:j], "'", "''") + "'"
+			if clob {
+				lit = "TO_CLOB(" + lit + ")"
+			}
+			parts = append(parts, lit)
+			line = line[j:]
+		}
+		if i == len(lines)-1 {
+			break
+		}
+		nl := "CHR(10)"
+		if cr {
+			nl = "CHR(13) || CHR(10)"
+		}
+		if len(parts) == 0 {
+			parts = append(parts, nl)
+		} else {
+			parts[len(parts)-1] += " || " + nl
+		}
+	}
+	return strings.Join(parts, "\n      || ")
+}
+
+// The separators of the SQL*Loader data file's fields and records.
+const (
+	loaderFieldSep  = "\x1f"
+	loaderRecordSep = "\x1e\n"
+)
+
+// OracleLoader writes the SQL*Loader control file, loading the data file named dataFile,
+// and the data file.
+func OracleLoader(ctl, dat io.Writer, dataFile string, opts OracleOptions, msgs iter.Seq2[oerr.Message, error]) error {
+	opts, err := opts.check()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(ctl)
+	opts.header(bw)
+	fmt.Fprintf(bw, `LOAD DATA
+CHARACTERSET AL32UTF8
+INFILE '%s' "STR X'1E0A'"
+APPEND INTO TABLE %s
+FIELDS TERMINATED BY X'1F'
+TRAILING NULLCOLS
+(
+  prefix CHAR(3),
+  code INTEGER EXTERNAL,
+  description CHAR(4000),
+  cause CHAR(1000000),
+  action CHAR(1000000)
+)
+`, strings.ReplaceAll(dataFile, "'", "''"), opts.Table)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	clean := strings.NewReplacer("\x1e", " ", "\x1f", " ").Replace
+	bw = bufio.NewWriter(dat)
+	for msg, err := range msgs {
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "%s%s%d%s%s%s%s%s%s%s",
+			msg.Prefix, loaderFieldSep, msg.Code, loaderFieldSep,
+			clean(msg.Description), loaderFieldSep, clean(msg.Cause), loaderFieldSep, clean(msg.Action),
+			loaderRecordSep)
+	}
+	return bw.Flush()
+}
+
+// OraclePackage writes the specification and the body of the PL/SQL package,
+// with the describe, cause and action functions.
+//
+// The functions accept SQLCODE, too (the code's sign is ignored),
+// and return NULL for unknown codes:
+//
+//	EXCEPTION WHEN OTHERS THEN
+//	  log_error(SQLERRM, oerr.cause(SQLCODE), oerr.action(SQLCODE));
+func OraclePackage(spec, body io.Writer, opts OracleOptions) error {
+	opts, err := opts.check()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(spec)
+	opts.header(bw)
+	fmt.Fprintf(bw, `CREATE OR REPLACE PACKAGE %[1]s AS
+  -- The description of the message, such as 'unique constraint (string.string) violated' for 1 or -1.
+  FUNCTION describe(p_code IN PLS_INTEGER, p_prefix IN VARCHAR2 DEFAULT 'ORA') RETURN VARCHAR2;
+  -- The cause of the message.
+  FUNCTION cause(p_code IN PLS_INTEGER, p_prefix IN VARCHAR2 DEFAULT 'ORA') RETURN CLOB;
+  -- The action to take.
+  FUNCTION action(p_code IN PLS_INTEGER, p_prefix IN VARCHAR2 DEFAULT 'ORA') RETURN CLOB;
+END %[1]s;
+/
+`, opts.Package)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	bw = bufio.NewWriter(body)
+	opts.header(bw)
+	fmt.Fprintf(bw, "CREATE OR REPLACE PACKAGE BODY %s AS\n", opts.Package)
+	for _, f := range []struct{ name, typ string }{
+		{"describe", "VARCHAR2"},
+		{"cause", "CLOB"},
+		{"action", "CLOB"},
+	} {
+		column := f.name
+		if column == "describe" {
+			column = "description"
+		}
+		fmt.Fprintf(bw, `
+FUNCTION %[1]s(p_code IN PLS_INTEGER, p_prefix IN VARCHAR2 DEFAULT 'ORA') RETURN %[2]s IS
+  v_res %[4]s.%[3]s%%TYPE;
+BEGIN
+  SELECT %[3]s INTO v_res FROM %[4]s WHERE prefix = UPPER(p_prefix) AND code = ABS(p_code);
+  RETURN v_res;
+EXCEPTION WHEN NO_DATA_FOUND THEN
+  RETURN NULL;
+END %[1]s;
+`, f.name, f.typ, column, opts.Table)
+	}
+	fmt.Fprintf(bw, "\nEND %s;\n/\n", opts.Package)
+	return bw.Flush()
+}
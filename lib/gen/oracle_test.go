@@ -0,0 +1,119 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package gen
+
+import (
+	"iter"
+	"strings"
+	"testing"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+var testMsgs = []oerr.Message{
+	{MsgID: oerr.MsgID{Prefix: "ORA", Code: 1}, MsgData: oerr.MsgData{Description: "unique constraint (string.string) violated", Cause: "An UPDATE or INSERT statement attempted to insert a duplicate key."}},
+	{MsgID: oerr.MsgID{Prefix: "ORA", Code: 60}, MsgData: oerr.MsgData{Description: "deadlock detected while waiting for resource"}},
+	{MsgID: oerr.MsgID{Prefix: "PLS", Code: 201}, MsgData: oerr.MsgData{Description: "identifier 'string' must be declared", Action: strings.Repeat("é", 2500)}},
+}
+
+func seqOf(msgs ...oerr.Message) iter.Seq2[oerr.Message, error] {
+	return func(yield func(oerr.Message, error) bool) {
+		for _, msg := range msgs {
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestOraString(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		clob bool
+		want string
+	}{
+		{"", false, "NULL"},
+		{"it's", false, "'it''s'"},
+		{strings.Repeat("a", 1500), false, "'" + strings.Repeat("a", 1000) + "'\n      || '" + strings.Repeat("a", 500) + "'"},
+		{strings.Repeat("'", 600), true, "TO_CLOB('" + strings.Repeat("''", 500) + "')\n      || TO_CLOB('" + strings.Repeat("''", 100) + "')"},
+		{"a;\n/\r\n\nb", false, "'a;' || CHR(10)\n      || '/' || CHR(13) || CHR(10) || CHR(10)\n      || 'b'"},
+		{"\nb\n", true, "CHR(10)\n      || TO_CLOB('b') || CHR(10)"},
+	} {
+		if got := oraString(tc.in, tc.clob); got != tc.want {
+			t.Errorf("%.10q: got %.40q, wanted %.40q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestOracleInserts(t *testing.T) {
+	var buf strings.Builder
+	if err := OracleInserts(&buf, OracleOptions{Batch: 2, Header: "test"}, seqOf(testMsgs...)); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, "-- test\n") {
+		t.Error("no header")
+	}
+	if n := strings.Count(got, "INSERT ALL\n"); n != 2 {
+		t.Errorf("got %d batches, wanted 2", n)
+	}
+	if n := strings.Count(got, "SELECT 1 FROM DUAL;\n"); n != 2 {
+		t.Errorf("got %d batch ends, wanted 2", n)
+	}
+	if !strings.Contains(got, "'identifier ''string'' must be declared'") {
+		t.Error("quote is not escaped")
+	}
+	if !strings.Contains(got, "    NULL,\n    TO_CLOB('é") {
+		t.Error("long action is not chunked")
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if len(line) > 2499 {
+			t.Errorf("line is too long: %.40q (%d)", line, len(line))
+		}
+	}
+}
+
+func TestOracleOptions(t *testing.T) {
+	var buf strings.Builder
+	for _, nm := range []string{"app.oerr_message", "OERR$1"} {
+		if err := OracleTable(&buf, OracleOptions{Table: nm}); err != nil {
+			t.Errorf("%q: %v", nm, err)
+		}
+	}
+	for _, nm := range []string{"x; DROP TABLE y", "1a", "a.b.c"} {
+		if err := OracleTable(&buf, OracleOptions{Table: nm}); err == nil {
+			t.Errorf("%q: wanted error", nm)
+		}
+	}
+	if !strings.Contains(buf.String(), "CONSTRAINT oerr_message_PK PRIMARY KEY") {
+		t.Errorf("got %s", buf.String())
+	}
+}
+
+func TestOracleLoader(t *testing.T) {
+	var ctl, dat strings.Builder
+	if err := OracleLoader(&ctl, &dat, "oerr.dat", OracleOptions{}, seqOf(testMsgs[:2]...)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ctl.String(), "INFILE 'oerr.dat'") {
+		t.Errorf("got %s", ctl.String())
+	}
+	want := "ORA\x1f1\x1funique constraint (string.string) violated\x1fAn UPDATE or INSERT statement attempted to insert a duplicate key.\x1f\x1e\n" +
+		"ORA\x1f60\x1fdeadlock detected while waiting for resource\x1f\x1f\x1e\n"
+	if got := dat.String(); got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
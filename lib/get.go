@@ -17,59 +17,130 @@ package oerr
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
 
-	"github.com/boltdb/bolt"
+	bolt "go.etcd.io/bbolt"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound = errors.New("not found")
+	ErrClosed   = errors.New("db is closed")
+	// ErrTimeout is returned when the DB file is locked by a writer for longer than Options.Timeout.
+	ErrTimeout = errors.New("timeout waiting for the DB lock")
+	// ErrNoBucket is returned when the file is a Bolt DB, but not an oerr DB.
+	ErrNoBucket = errors.New("no " + bucketName + " bucket in the DB")
+)
 
-// Open the DB, read-only mode.
-func Open(dbPath string) (GetCloser, error) {
-	db, err := bolt.Open(dbPath, 0664, &bolt.Options{ReadOnly: true})
-	if err != nil {
-		return nil, err
+// OpenError is returned by Open when the DB cannot be opened.
+type OpenError struct {
+	Path string
+	Err  error
+}
+
+func (e *OpenError) Error() string { return fmt.Sprintf("open %q: %v", e.Path, e.Err) }
+func (e *OpenError) Unwrap() error { return e.Err }
+
+// Options for OpenOptions.
+type Options struct {
+	// Timeout is the time to wait for the file lock, 0 means wait indefinitely.
+	Timeout time.Duration
+}
+
+// DefaultOptions are used by Open.
+var DefaultOptions = Options{Timeout: 5 * time.Second}
+
+// Open the DB, read-only mode, with DefaultOptions.
+func Open(dbPath string) (DB, error) {
+	return OpenOptions(dbPath, DefaultOptions)
+}
+
+// OpenOptions opens the DB in read-only mode.
+//
+// The returned DB is safe for concurrent use: each call runs in its own
+// read transaction, so no transaction is held between calls.
+func OpenOptions(dbPath string, opts Options) (DB, error) {
+	// bolt would create the missing file
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, &OpenError{Path: dbPath, Err: err}
 	}
-	tx, err := db.Begin(false)
+	db, err := bolt.Open(dbPath, 0664, &bolt.Options{ReadOnly: true, Timeout: opts.Timeout})
 	if err != nil {
-		return nil, err
+		if err == bolt.ErrTimeout {
+			err = ErrTimeout
+		}
+		return nil, &OpenError{Path: dbPath, Err: err}
+	}
+	if err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(bucketName)) == nil && tx.Bucket([]byte(overridesBucketName)) == nil {
+			return ErrNoBucket
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, &OpenError{Path: dbPath, Err: err}
 	}
-	return dbS{tx.Bucket([]byte(bucketName))}, nil
+	return &dbS{db: db}, nil
 }
 
 type dbS struct {
-	*bolt.Bucket
+	mu sync.RWMutex
+	db *bolt.DB
 }
 
-func (db dbS) Get(id MsgID) (data MsgData, err error) {
-	if db.Bucket == nil {
-		return data, errors.New("db is closed")
+// view calls f in a new read transaction.
+func (db *dbS) view(f func(tx *bolt.Tx) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.db == nil {
+		return ErrClosed
 	}
+	return db.db.View(f)
+}
+
+func (db *dbS) Get(id MsgID) (data MsgData, err error) {
 	key, err := id.MarshalBinary()
 	if err != nil {
 		return data, err
 	}
-	val := db.Bucket.Get(key)
-	if len(val) == 0 {
-		return data, ErrNotFound
-	}
-	err = data.UnmarshalBinary(val)
+	err = db.view(func(tx *bolt.Tx) error {
+		val := get(tx, key)
+		if len(val) == 0 {
+			return ErrNotFound
+		}
+		return data.UnmarshalBinary(val)
+	})
 	return data, err
 }
 
-func (db dbS) Close() error {
-	if db.Bucket == nil {
-		return nil
+// get returns the override for the key if there is one, else the downloaded message.
+func get(tx *bolt.Tx, key []byte) []byte {
+	if bucket := tx.Bucket([]byte(overridesBucketName)); bucket != nil {
+		if val := bucket.Get(key); val != nil {
+			if isTombstone(val) {
+				return nil
+			}
+			return val
+		}
 	}
-	tx := db.Bucket.Tx()
-	if tx == nil {
-		return nil
+	if bucket := tx.Bucket([]byte(bucketName)); bucket != nil {
+		return bucket.Get(key)
 	}
-	realDB := tx.DB()
-	tx.Rollback()
-	if realDB == nil {
+	return nil
+}
+
+// Close the DB, waiting for the running reads to finish.
+func (db *dbS) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.db == nil {
 		return nil
 	}
-	return realDB.Close()
+	err := db.db.Close()
+	db.db = nil
+	return err
 }
 
 type GetCloser interface {
@@ -0,0 +1,80 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestGetConcurrent(t *testing.T) {
+	db, err := Open(newTestDB(t, testMsgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				msg := testMsgs[j%len(testMsgs)]
+				data, err := db.Get(msg.MsgID)
+				if err != nil {
+					t.Errorf("Get(%s): %v", msg.MsgID, err)
+					return
+				}
+				if data != msg.MsgData {
+					t.Errorf("Get(%s): got %v, wanted %v", msg.MsgID, data, msg.MsgData)
+				}
+			}
+			if n := len(collect(t, db.All())); n != len(testMsgs) {
+				t.Errorf("All: got %d, wanted %d", n, len(testMsgs))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := db.Get(MsgID{"ORA", 2}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(ORA-00002): got %v, wanted ErrNotFound", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Get(testMsgs[0].MsgID); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get after Close: got %v, wanted ErrClosed", err)
+	}
+}
+
+func TestOpenTimeout(t *testing.T) {
+	dbPath := newTestDB(t, testMsgs)
+	w, err := bolt.Open(dbPath, 0664, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	_, err = OpenOptions(dbPath, Options{Timeout: 100 * time.Millisecond})
+	var oe *OpenError
+	if !errors.As(err, &oe) || !errors.Is(err, ErrTimeout) {
+		t.Errorf("got %#v, wanted OpenError with ErrTimeout", err)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxCode is the biggest code which fits in the five digits of MsgID.String.
+const MaxCode = 99999
+
+// RecordError is a bad record of the input.
+type RecordError struct {
+	Line int
+	ID   string
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d [%s]: %v", e.Line, e.ID, e.Err)
+}
+func (e *RecordError) Unwrap() error { return e.Err }
+
+// Validate the record: the prefix must be three uppercase letters, the code at most MaxCode,
+// the description is required, and the ID must agree with the prefix and the code.
+// The missing prefix and code are filled from the ID.
+func (r *Record) Validate() error {
+	if r.ID != "" {
+		i := strings.LastIndexByte(r.ID, '-')
+		code, err := strconv.ParseUint(r.ID[i+1:], 10, 32)
+		if i < 0 || err != nil {
+			return fmt.Errorf("bad id %q", r.ID)
+		}
+		if r.Prefix == "" && r.Code == 0 {
+			r.Prefix, r.Code = r.ID[:i], uint32(code)
+		} else if r.Prefix != r.ID[:i] || uint64(r.Code) != code {
+			return fmt.Errorf("id %q does not match %s-%05d", r.ID, r.Prefix, r.Code)
+		}
+	}
+	if len(r.Prefix) != 3 || strings.IndexFunc(r.Prefix, func(c rune) bool { return c < 'A' || 'Z' < c }) >= 0 {
+		return fmt.Errorf("prefix %q is not three uppercase letters", r.Prefix)
+	}
+	if r.Code > MaxCode {
+		return fmt.Errorf("code %d is bigger than %d", r.Code, MaxCode)
+	}
+	if strings.TrimSpace(r.Description) == "" {
+		return errors.New("description is required")
+	}
+	if r.ID == "" {
+		r.ID = r.Message().MsgID.String()
+	}
+	return nil
+}
+
+// FormatOf returns the format by the file name's extension: json, jsonl (.ndjson) or csv.
+func FormatOf(fileName string) string {
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".ndjson":
+		return "jsonl"
+	case ".json", ".jsonl", ".csv":
+		return ext[1:]
+	}
+	return ""
+}
+
+// DecodeRecords reads the records of the format (json, jsonl or csv), validating them.
+//
+// Bad records are yielded as *RecordError, and the decoding goes on;
+// other errors stop it.
+func DecodeRecords(r io.Reader, format string) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		check := func(line int, rec Record, err error) bool {
+			if err == nil {
+				err = rec.Validate()
+			}
+			if err != nil {
+				return yield(rec, &RecordError{Line: line, ID: rec.ID, Err: err})
+			}
+			return yield(rec, nil)
+		}
+		switch format {
+		case "jsonl":
+			scanner := bufio.NewScanner(r)
+			scanner.Buffer(nil, 1<<20)
+			for line := 1; scanner.Scan(); line++ {
+				b := bytes.TrimSpace(scanner.Bytes())
+				if len(b) == 0 {
+					continue
+				}
+				var rec Record
+				err := json.Unmarshal(b, &rec)
+				if !check(line, rec, err) {
+					return
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				yield(Record{}, err)
+			}
+
+		case "json":
+			data, err := io.ReadAll(r)
+			if err != nil {
+				yield(Record{}, err)
+				return
+			}
+			lineOf := func(off int64) int {
+				for off < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,"), data[off]) >= 0 {
+					off++
+				}
+				return 1 + bytes.Count(data[:off], []byte{'\n'})
+			}
+			dec := json.NewDecoder(bytes.NewReader(data))
+			if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+				yield(Record{}, &RecordError{Line: lineOf(0), Err: errors.New("not a JSON array")})
+				return
+			}
+			for dec.More() {
+				line := lineOf(dec.InputOffset())
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					yield(Record{}, &RecordError{Line: line, Err: err})
+					return
+				}
+				var rec Record
+				err := json.Unmarshal(raw, &rec)
+				if !check(line, rec, err) {
+					return
+				}
+			}
+
+		case "csv":
+			cr := csv.NewReader(r)
+			cr.FieldsPerRecord = -1
+			header, err := cr.Read()
+			if err != nil {
+				yield(Record{}, &RecordError{Line: 1, Err: err})
+				return
+			}
+			cols := make(map[string]int, len(header))
+			for i, h := range header {
+				cols[strings.ToLower(strings.TrimSpace(h))] = i
+			}
+			for {
+				row, err := cr.Read()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					// FieldPos panics after an error
+					var pe *csv.ParseError
+					if !errors.As(err, &pe) {
+						yield(Record{}, err)
+						return
+					}
+					if !yield(Record{}, &RecordError{Line: pe.StartLine, Err: err}) {
+						return
+					}
+					continue
+				}
+				line, _ := cr.FieldPos(0)
+				rec, err := csvRecord(cols, row)
+				if !check(line, rec, err) {
+					return
+				}
+			}
+
+		default:
+			yield(Record{}, fmt.Errorf("cannot import %q format", format))
+		}
+	}
+}
+
+func csvRecord(cols map[string]int, row []string) (Record, error) {
+	get := func(nm string) string {
+		if i, ok := cols[nm]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	rec := Record{
+		ID: get("id"), Prefix: get("prefix"),
+		Description: get("description"), Cause: get("cause"), Action: get("action"),
+		Layer: get("layer"), Source: get("source"), Created: get("created"),
+		Override: get("override") == "true",
+	}
+	if s := get("code"); s != "" {
+		code, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return rec, fmt.Errorf("code %q: %w", s, err)
+		}
+		rec.Code = uint32(code)
+	}
+	return rec, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeRecords(t *testing.T) {
+	for _, tc := range []struct {
+		format, input string
+		ids           []string
+		badLines      []int
+	}{
+		{"jsonl", `{"id":"ORA-20001","description":"order not found"}
+
+{"prefix":"ORA","code":20002,"description":""}
+{"prefix":"ora","code":20003,"description":"x"}
+{"id":"PLS-00201","prefix":"PLS","code":202,"description":"x"}
+{"prefix":"APP","code":100000,"description":"x"}
+not json
+{"prefix":"APP","code":1,"description":"ok"}
+`, []string{"ORA-20001", "APP-00001"}, []int{3, 4, 5, 6, 7}},
+		{"json", `[
+  {"id": "ORA-20001", "description": "order not found"},
+  {"id": "ORA-20002"},
+  {"id": "ORA-20003", "description": "ok"}
+]`, []string{"ORA-20001", "ORA-20003"}, []int{3}},
+		{"csv", `id,description,cause
+ORA-20001,order not found,
+"ORA-20002","",no description
+ORA-20003,"multi
+line",
+XX-1,bad prefix,
+"ORA-1"x,foo
+ORA-20004,after the bad quote,
+`, []string{"ORA-20001", "ORA-20003", "ORA-20004"}, []int{3, 6, 7}},
+	} {
+		var ids []string
+		var badLines []int
+		for rec, err := range DecodeRecords(strings.NewReader(tc.input), tc.format) {
+			var re *RecordError
+			if errors.As(err, &re) {
+				badLines = append(badLines, re.Line)
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", tc.format, err)
+			}
+			ids = append(ids, rec.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(tc.ids, ",") {
+			t.Errorf("%s: got %v, wanted %v", tc.format, ids, tc.ids)
+		}
+		if len(badLines) != len(tc.badLines) {
+			t.Errorf("%s: got bad lines %v, wanted %v", tc.format, badLines, tc.badLines)
+			continue
+		}
+		for i, line := range badLines {
+			if line != tc.badLines[i] {
+				t.Errorf("%s: got bad lines %v, wanted %v", tc.format, badLines, tc.badLines)
+				break
+			}
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	db, err := NewMemDB(nil, seqOf(testMsgs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"json", "jsonl", "csv"} {
+		var buf bytes.Buffer
+		if err := Export(&buf, format, db, db.All()); err != nil {
+			t.Fatal(err)
+		}
+		var i int
+		for rec, err := range DecodeRecords(&buf, format) {
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if msg := rec.Message(); msg != testMsgs[i] {
+				t.Errorf("%s: got %v, wanted %v", format, msg, testMsgs[i])
+			}
+			i++
+		}
+		if i != len(testMsgs) {
+			t.Errorf("%s: got %d records, wanted %d", format, i, len(testMsgs))
+		}
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for fn, want := range map[string]string{
+		"a.json": "json", "a.JSONL": "jsonl", "a.ndjson": "jsonl", "a.csv": "csv",
+		"a.yaml": "", "a.txt": "",
+	} {
+		if got := FormatOf(fn); got != want {
+			t.Errorf("%s: got %q, wanted %q", fn, got, want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"iter"
+	"os"
+)
+
+// Layer is one DB of a Layered.
+type Layer struct {
+	Name string
+	DB   DB
+}
+
+// Layered consults its layers in order, the first one having the message answers.
+//
+// A deletion in an upper layer does not hide the message of a lower layer.
+type Layered struct {
+	Layers []Layer
+}
+
+var (
+	_ DB         = (*Layered)(nil)
+	_ NoteLister = (*Layered)(nil)
+)
+
+// OpenLayered opens the DBs of the search path (see SplitSearchPath and OpenURL),
+// skipping the non-existent ones.
+func OpenLayered(searchPath string, opts Options) (*Layered, error) {
+	var l Layered
+	for _, dbPath := range SplitSearchPath(searchPath) {
+		db, err := OpenURL(dbPath, opts)
+		if err != nil && errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.Layers = append(l.Layers, Layer{Name: dbPath, DB: db})
+	}
+	if len(l.Layers) == 0 {
+		return nil, &OpenError{Path: searchPath, Err: os.ErrNotExist}
+	}
+	return &l, nil
+}
+
+// GetLayer returns the message and the name of the layer which answered.
+func (l *Layered) GetLayer(id MsgID) (MsgData, string, error) {
+	for _, layer := range l.Layers {
+		data, err := layer.DB.Get(id)
+		if err == nil {
+			return data, layer.Name, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return data, layer.Name, err
+		}
+	}
+	return MsgData{}, "", ErrNotFound
+}
+
+func (l *Layered) Get(id MsgID) (MsgData, error) {
+	data, _, err := l.GetLayer(id)
+	return data, err
+}
+
+// Meta returns the metadata of all the layers, the upper ones winning.
+func (l *Layered) Meta() map[string]string {
+	m := make(map[string]string)
+	for i := len(l.Layers) - 1; i >= 0; i-- {
+		for k, v := range l.Layers[i].DB.Meta() {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+func (l *Layered) All() iter.Seq2[Message, error] {
+	return l.merge(func(db DB) iter.Seq2[Message, error] { return db.All() })
+}
+
+func (l *Layered) Prefix(prefix string) iter.Seq2[Message, error] {
+	return l.merge(func(db DB) iter.Seq2[Message, error] { return db.Prefix(prefix) })
+}
+
+func (l *Layered) Range(from, to MsgID) iter.Seq2[Message, error] {
+	return l.merge(func(db DB) iter.Seq2[Message, error] { return db.Range(from, to) })
+}
+
+// merge the ordered sequences of the layers, the upper layers shadowing the lower ones.
+func (l *Layered) merge(f func(DB) iter.Seq2[Message, error]) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		type head struct {
+			next func() (Message, error, bool)
+			msg  Message
+			ok   bool
+		}
+		heads := make([]head, len(l.Layers))
+		for i, layer := range l.Layers {
+			next, stop := iter.Pull2(f(layer.DB))
+			defer stop()
+			heads[i].next = next
+		}
+		advance := func(h *head) bool {
+			for {
+				var err error
+				if h.msg, err, h.ok = h.next(); err == nil {
+					return true
+				}
+				if !yield(Message{}, err) {
+					return false
+				}
+			}
+		}
+		for i := range heads {
+			if !advance(&heads[i]) {
+				return
+			}
+		}
+		for {
+			var minID *MsgID
+			for i := range heads {
+				if heads[i].ok && (minID == nil || heads[i].msg.MsgID.Compare(*minID) < 0) {
+					minID = &heads[i].msg.MsgID
+				}
+			}
+			if minID == nil {
+				return
+			}
+			id, first := *minID, true
+			for i := range heads {
+				if !heads[i].ok || heads[i].msg.MsgID != id {
+					continue
+				}
+				if first {
+					first = false
+					if !yield(heads[i].msg, nil) {
+						return
+					}
+				}
+				if !advance(&heads[i]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Notes of all the layers, the upper ones first.
+func (l *Layered) Notes(id MsgID) ([]Note, error) {
+	var notes []Note
+	for _, layer := range l.Layers {
+		if nl, ok := layer.DB.(NoteLister); ok {
+			ns, err := nl.Notes(id)
+			if err != nil {
+				return notes, err
+			}
+			notes = append(notes, ns...)
+		}
+	}
+	return notes, nil
+}
+
+// AllNotes returns the notes of each layer after each other.
+func (l *Layered) AllNotes() iter.Seq2[Note, error] {
+	return func(yield func(Note, error) bool) {
+		for _, layer := range l.Layers {
+			if nl, ok := layer.DB.(NoteLister); ok {
+				for n, err := range nl.AllNotes() {
+					if !yield(n, err) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// Close all the layers, returning the first error.
+func (l *Layered) Close() error {
+	var firstErr error
+	for _, layer := range l.Layers {
+		if err := layer.DB.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
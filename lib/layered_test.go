@@ -0,0 +1,69 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLayered(t *testing.T) {
+	team := newTestDB(t, []Message{
+		{MsgID{"ORA", 60}, MsgData{Description: "deadlock: see the wiki"}},
+		{MsgID{"ORA", 20001}, MsgData{Description: "order not found"}},
+	})
+	base := newTestDB(t, testMsgs)
+	searchPath := strings.Join([]string{team, filepath.Join(t.TempDir(), "missing.db"), base}, string(filepath.ListSeparator))
+	l, err := OpenLayered(searchPath, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if len(l.Layers) != 2 {
+		t.Fatalf("got %d layers, wanted 2", len(l.Layers))
+	}
+
+	for _, tc := range []struct {
+		id    MsgID
+		desc  string
+		layer string
+	}{
+		{MsgID{"ORA", 60}, "deadlock: see the wiki", team},
+		{MsgID{"ORA", 1}, testMsgs[0].Description, base},
+	} {
+		data, layer, err := l.GetLayer(tc.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data.Description != tc.desc || layer != tc.layer {
+			t.Errorf("%s: got %q from %q, wanted %q from %q", tc.id, data.Description, layer, tc.desc, tc.layer)
+		}
+	}
+
+	var ids []MsgID
+	for _, msg := range collect(t, l.Prefix("ORA")) {
+		ids = append(ids, msg.MsgID)
+		if msg.Code == 60 && msg.Description != "deadlock: see the wiki" {
+			t.Errorf("ORA-00060 is not shadowed: %q", msg.Description)
+		}
+	}
+	want := []MsgID{{"ORA", 1}, {"ORA", 60}, {"ORA", 1500}, {"ORA", 1555}, {"ORA", 1600}, {"ORA", 20001}}
+	if !slices.Equal(ids, want) {
+		t.Errorf("got %v, wanted %v", ids, want)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"iter"
+	"math"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Lister enumerates the messages in (Prefix, Code) order.
+type Lister interface {
+	// All messages.
+	All() iter.Seq2[Message, error]
+	// Prefix returns the messages whose prefix starts with the given string.
+	Prefix(prefix string) iter.Seq2[Message, error]
+	// Range returns the messages between from and to, inclusive.
+	Range(from, to MsgID) iter.Seq2[Message, error]
+}
+
+// DB is what Open returns: Get, Close, the Lister methods and the build metadata.
+type DB interface {
+	GetCloser
+	Lister
+	Meta() map[string]string
+}
+
+// MaxID returns the last possible MsgID with the given prefix.
+func MaxID(prefix string) MsgID {
+	return MsgID{Prefix: prefix, Code: math.MaxUint32}
+}
+
+func (db *dbS) All() iter.Seq2[Message, error] {
+	return db.seek(nil, func([]byte) bool { return true })
+}
+
+func (db *dbS) Prefix(prefix string) iter.Seq2[Message, error] {
+	p := []byte(strings.ToUpper(prefix))
+	return db.seek(p, func(k []byte) bool { return bytes.HasPrefix(k, p) })
+}
+
+func (db *dbS) Range(from, to MsgID) iter.Seq2[Message, error] {
+	start, err := from.MarshalBinary()
+	if err != nil {
+		return errSeq(err)
+	}
+	end, err := to.MarshalBinary()
+	if err != nil {
+		return errSeq(err)
+	}
+	return db.seek(start, func(k []byte) bool { return bytes.Compare(k, end) <= 0 })
+}
+
+// seekBatch is the number of messages read in one read transaction by seek.
+const seekBatch = 256
+
+// seek positions a cursor at start (the first key if start is nil),
+// and yields the messages till ok returns false.
+//
+// The messages are read in batches, each in its own read transaction,
+// and yielded outside of it: the loop body may call Get or Close.
+func (db *dbS) seek(start []byte, ok func(key []byte) bool) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		from := start // the Seq can be ranged over again
+		batch := make([]Message, 0, seekBatch)
+		for {
+			batch = batch[:0]
+			var last []byte
+			var done bool
+			err := db.view(func(tx *bolt.Tx) error {
+				c := newMergedCursor(tx)
+				var k, v []byte
+				if from == nil {
+					k, v = c.First()
+				} else {
+					k, v = c.Seek(from)
+				}
+				for ; len(batch) < seekBatch; k, v = c.Next() {
+					if k == nil || !ok(k) {
+						done = true
+						return nil
+					}
+					var msg Message
+					if err := msg.MsgID.UnmarshalBinary(k); err != nil {
+						return err
+					}
+					if err := msg.MsgData.UnmarshalBinary(v); err != nil {
+						return err
+					}
+					batch = append(batch, msg)
+					last = append(last[:0], k...) // k is valid only in the transaction
+				}
+				// the batch is full: peek whether there are more
+				done = k == nil || !ok(k)
+				return nil
+			})
+			for _, msg := range batch {
+				if !yield(msg, nil) {
+					return
+				}
+			}
+			if err != nil {
+				yield(Message{}, err)
+				return
+			}
+			if done {
+				return
+			}
+			// the smallest key after the last one
+			from = append(last, 0)
+		}
+	}
+}
+
+// mergedCursor iterates over the overrides and the downloaded messages,
+// the overrides shadowing the downloaded ones, tombstones hiding them.
+type mergedCursor struct {
+	over, base     *bolt.Cursor
+	ok, ov, bk, bv []byte
+}
+
+func newMergedCursor(tx *bolt.Tx) *mergedCursor {
+	var c mergedCursor
+	if bucket := tx.Bucket([]byte(overridesBucketName)); bucket != nil {
+		c.over = bucket.Cursor()
+	}
+	if bucket := tx.Bucket([]byte(bucketName)); bucket != nil {
+		c.base = bucket.Cursor()
+	}
+	return &c
+}
+
+func (c *mergedCursor) First() (key, value []byte) {
+	if c.over != nil {
+		c.ok, c.ov = c.over.First()
+	}
+	if c.base != nil {
+		c.bk, c.bv = c.base.First()
+	}
+	return c.current()
+}
+
+func (c *mergedCursor) Seek(seek []byte) (key, value []byte) {
+	if c.over != nil {
+		c.ok, c.ov = c.over.Seek(seek)
+	}
+	if c.base != nil {
+		c.bk, c.bv = c.base.Seek(seek)
+	}
+	return c.current()
+}
+
+func (c *mergedCursor) Next() (key, value []byte) {
+	if k, _ := c.current(); k != nil {
+		c.advance(k)
+	}
+	return c.current()
+}
+
+func (c *mergedCursor) current() (key, value []byte) {
+	for {
+		if c.ok == nil || c.bk != nil && bytes.Compare(c.bk, c.ok) < 0 {
+			return c.bk, c.bv
+		}
+		if !isTombstone(c.ov) {
+			return c.ok, c.ov
+		}
+		c.advance(c.ok)
+	}
+}
+
+// advance the cursors standing at k.
+func (c *mergedCursor) advance(k []byte) {
+	if c.bk != nil && bytes.Equal(c.bk, k) {
+		c.bk, c.bv = c.base.Next()
+	}
+	if c.ok != nil && bytes.Equal(c.ok, k) {
+		c.ok, c.ov = c.over.Next()
+	}
+}
+
+func errSeq(err error) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) { yield(Message{}, err) }
+}
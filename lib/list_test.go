@@ -0,0 +1,196 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"iter"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var testMsgs = []Message{
+	{MsgID{"ORA", 1}, MsgData{Description: "unique constraint (string.string) violated"}},
+	{MsgID{"ORA", 60}, MsgData{Description: "deadlock detected while waiting for resource"}},
+	{MsgID{"ORA", 1500}, MsgData{Description: "internal error in SQL*Loader"}},
+	{MsgID{"ORA", 1555}, MsgData{Description: "snapshot too old"}},
+	{MsgID{"ORA", 1600}, MsgData{Description: "at most one string in clause string of string"}},
+	{MsgID{"PLS", 201}, MsgData{Description: "identifier 'string' must be declared"}},
+	{MsgID{"TNS", 12154}, MsgData{Description: "TNS:could not resolve the connect identifier specified"}},
+}
+
+// newTestDB writes the messages into a new Bolt DB, and returns its path.
+func newTestDB(t testing.TB, msgs []Message) string {
+	dbPath := filepath.Join(t.TempDir(), "oerr.db")
+	db, err := bolt.Open(dbPath, 0664, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(bucketName))
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			key, _ := msg.MsgID.MarshalBinary()
+			val, _ := msg.MsgData.MarshalBinary()
+			if err := bucket.Put(key, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return dbPath
+}
+
+func TestList(t *testing.T) {
+	db, err := Open(newTestDB(t, testMsgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, tc := range []struct {
+		name string
+		msgs func() []Message
+		want []MsgID
+	}{
+		{"all", func() []Message { return collect(t, db.All()) },
+			[]MsgID{{"ORA", 1}, {"ORA", 60}, {"ORA", 1500}, {"ORA", 1555}, {"ORA", 1600}, {"PLS", 201}, {"TNS", 12154}}},
+		{"prefix", func() []Message { return collect(t, db.Prefix("pls")) },
+			[]MsgID{{"PLS", 201}}},
+		{"range", func() []Message { return collect(t, db.Range(MsgID{"ORA", 1500}, MsgID{"ORA", 1600})) },
+			[]MsgID{{"ORA", 1500}, {"ORA", 1555}, {"ORA", 1600}}},
+		{"open", func() []Message { return collect(t, db.Range(MsgID{"ORA", 1501}, MaxID("ORA"))) },
+			[]MsgID{{"ORA", 1555}, {"ORA", 1600}}},
+	} {
+		got := tc.msgs()
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %d messages, wanted %d", tc.name, len(got), len(tc.want))
+			continue
+		}
+		for i, msg := range got {
+			if msg.MsgID != tc.want[i] {
+				t.Errorf("%s: %d. got %s, wanted %s", tc.name, i, msg.MsgID, tc.want[i])
+			}
+		}
+	}
+}
+
+func collect(t testing.TB, seq iter.Seq2[Message, error]) []Message {
+	var msgs []Message
+	for msg, err := range seq {
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func seqOf(msgs ...Message) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		for _, msg := range msgs {
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestIterateConcurrently(t *testing.T) {
+	msgs := make([]Message, 3*seekBatch)
+	for i := range msgs {
+		msgs[i] = Message{MsgID{"ORA", uint32(i + 1)}, MsgData{Description: "x"}}
+	}
+	dbPath := newTestDB(t, msgs)
+
+	for _, closeInLoop := range []bool{false, true} {
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan error, 1)
+		go func() {
+			var n int
+			var closed chan struct{}
+			for msg, err := range db.All() {
+				if err != nil {
+					done <- err
+					return
+				}
+				if _, err := db.Get(msg.MsgID); err != nil && !errors.Is(err, ErrClosed) {
+					done <- err
+					return
+				}
+				if n++; n == seekBatch/2 {
+					if closeInLoop {
+						db.Close()
+					} else {
+						closed = make(chan struct{})
+						go func() { db.Close(); close(closed) }()
+					}
+				}
+			}
+			if closed != nil {
+				<-closed
+			}
+			done <- nil
+		}()
+		select {
+		case err := <-done:
+			if err != nil && !errors.Is(err, ErrClosed) {
+				t.Errorf("close in loop=%t: %+v", closeInLoop, err)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("close in loop=%t: deadlock", closeInLoop)
+		}
+	}
+}
+
+func TestIterateTwice(t *testing.T) {
+	msgs := make([]Message, 3*seekBatch)
+	for i := range msgs {
+		msgs[i] = Message{MsgID{"ORA", uint32(i + 1)}, MsgData{Description: "x"}}
+	}
+	db, err := Open(newTestDB(t, msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for name, seq := range map[string]iter.Seq2[Message, error]{
+		"all": db.All(), "prefix": db.Prefix("ORA"),
+	} {
+		for i := range 2 {
+			var n int
+			for _, err := range seq {
+				if err != nil {
+					t.Fatal(err)
+				}
+				n++
+			}
+			if n != len(msgs) {
+				t.Errorf("%s #%d: got %d, wanted %d", name, i+1, n, len(msgs))
+			}
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GRPCCode is a gRPC status code, convertible to google.golang.org/grpc/codes.Code.
+type GRPCCode uint32
+
+// The gRPC status codes.
+const (
+	GRPCOK GRPCCode = iota
+	GRPCCanceled
+	GRPCUnknown
+	GRPCInvalidArgument
+	GRPCDeadlineExceeded
+	GRPCNotFound
+	GRPCAlreadyExists
+	GRPCPermissionDenied
+	GRPCResourceExhausted
+	GRPCFailedPrecondition
+	GRPCAborted
+	GRPCOutOfRange
+	GRPCUnimplemented
+	GRPCInternal
+	GRPCUnavailable
+	GRPCDataLoss
+	GRPCUnauthenticated
+)
+
+var grpcNames = []string{"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded",
+	"NotFound", "AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition",
+	"Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable", "DataLoss", "Unauthenticated"}
+
+func (c GRPCCode) String() string {
+	if int(c) < len(grpcNames) {
+		return grpcNames[c]
+	}
+	return "Code(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// MarshalText returns the name of the code.
+func (c GRPCCode) MarshalText() ([]byte, error) { return []byte(c.String()), nil }
+
+// UnmarshalText parses the name (AlreadyExists, ALREADY_EXISTS) or the number of the code.
+func (c *GRPCCode) UnmarshalText(text []byte) error {
+	s := strings.ReplaceAll(string(text), "_", "")
+	if i := slices.IndexFunc(grpcNames, func(nm string) bool { return strings.EqualFold(nm, s) }); i >= 0 {
+		*c = GRPCCode(i)
+		return nil
+	}
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil && n < uint64(len(grpcNames)) {
+		*c = GRPCCode(n)
+		return nil
+	}
+	return fmt.Errorf("unknown gRPC code %q", text)
+}
+
+// Mapping of a message to the ANSI SQLSTATE, the HTTP status and the gRPC code
+// to be reported for it. The zero fields are unspecified.
+type Mapping struct {
+	SQLState string   `json:"sqlstate,omitempty"`
+	HTTP     int      `json:"http,omitempty"`
+	GRPC     GRPCCode `json:"grpc,omitempty"`
+}
+
+func (m Mapping) String() string {
+	return fmt.Sprintf("SQLSTATE %s, HTTP %d %s, gRPC %s", m.SQLState, m.HTTP, http.StatusText(m.HTTP), m.GRPC)
+}
+
+// Validate the specified fields.
+func (m Mapping) Validate() error {
+	if m.SQLState != "" && (len(m.SQLState) != 5 ||
+		strings.IndexFunc(m.SQLState, func(r rune) bool { return !('0' <= r && r <= '9' || 'A' <= r && r <= 'Z') }) >= 0) {
+		return fmt.Errorf("bad SQLSTATE %q: must be five digits or uppercase letters", m.SQLState)
+	}
+	if m.HTTP != 0 && (m.HTTP < 100 || m.HTTP > 599) {
+		return fmt.Errorf("bad HTTP status %d", m.HTTP)
+	}
+	if int(m.GRPC) >= len(grpcNames) {
+		return fmt.Errorf("bad gRPC code %d", m.GRPC)
+	}
+	return nil
+}
+
+// overlay returns m with the specified fields of o.
+func (m Mapping) overlay(o Mapping) Mapping {
+	if o.SQLState != "" {
+		m.SQLState = o.SQLState
+	}
+	if o.HTTP != 0 {
+		m.HTTP = o.HTTP
+	}
+	if o.GRPC != 0 {
+		m.GRPC = o.GRPC
+	}
+	return m
+}
+
+// defaultMapping is for the unclassified errors.
+var defaultMapping = Mapping{"HY000", http.StatusInternalServerError, GRPCInternal}
+
+// classMappings are the mappings of the classes, in order of precedence.
+var classMappings = []struct {
+	Class
+	Mapping
+}{
+	{ClassConnection, Mapping{"08006", http.StatusServiceUnavailable, GRPCUnavailable}},
+	{ClassTimeout, Mapping{"HYT00", http.StatusGatewayTimeout, GRPCDeadlineExceeded}},
+	{ClassRetryable, Mapping{"40001", http.StatusConflict, GRPCAborted}},
+	{ClassTransient, Mapping{"53000", http.StatusServiceUnavailable, GRPCUnavailable}},
+	{ClassConstraint, Mapping{"23000", http.StatusConflict, GRPCFailedPrecondition}},
+	{ClassPermission, Mapping{"42000", http.StatusForbidden, GRPCPermissionDenied}},
+	{ClassNoData, Mapping{"02000", http.StatusNotFound, GRPCNotFound}},
+	{ClassData, Mapping{"22000", http.StatusBadRequest, GRPCInvalidArgument}},
+}
+
+// builtinMappings are the ORA errors mapped more specifically than by their classes.
+var builtinMappings = map[uint32]Mapping{
+	1:     {"23000", http.StatusConflict, GRPCAlreadyExists},
+	1013:  {"HY008", 499, GRPCCanceled}, // 499 Client Closed Request, as grpc-gateway maps Canceled
+	1017:  {"28000", http.StatusUnauthorized, GRPCUnauthenticated},
+	1045:  {"28000", http.StatusForbidden, GRPCPermissionDenied},
+	1400:  {"23000", http.StatusBadRequest, GRPCInvalidArgument},
+	1407:  {"23000", http.StatusBadRequest, GRPCInvalidArgument},
+	1426:  {"22003", http.StatusBadRequest, GRPCOutOfRange},
+	1438:  {"22003", http.StatusBadRequest, GRPCOutOfRange},
+	1476:  {"22012", http.StatusBadRequest, GRPCInvalidArgument},
+	1722:  {"22018", http.StatusBadRequest, GRPCInvalidArgument},
+	1830:  {"22007", http.StatusBadRequest, GRPCInvalidArgument},
+	1843:  {"22008", http.StatusBadRequest, GRPCInvalidArgument},
+	1858:  {"22007", http.StatusBadRequest, GRPCInvalidArgument},
+	1861:  {"22007", http.StatusBadRequest, GRPCInvalidArgument},
+	2290:  {"23000", http.StatusBadRequest, GRPCInvalidArgument},
+	12899: {"22001", http.StatusBadRequest, GRPCInvalidArgument},
+	28000: {"28000", http.StatusForbidden, GRPCPermissionDenied},
+	28001: {"28000", http.StatusUnauthorized, GRPCUnauthenticated},
+}
+
+var registeredMappings struct {
+	sync.RWMutex
+	m map[MsgID]Mapping
+}
+
+// SetMapping registers the mapping of the message, its specified fields
+// overriding the built-in ones.
+func SetMapping(id MsgID, m Mapping) error {
+	if err := m.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", id, err)
+	}
+	registeredMappings.Lock()
+	defer registeredMappings.Unlock()
+	if registeredMappings.m == nil {
+		registeredMappings.m = make(map[MsgID]Mapping)
+	}
+	registeredMappings.m[id] = m
+	return nil
+}
+
+// LoadMappings registers the mappings of the JSON object keyed by the message IDs:
+//
+//	{"ORA-00001": {"sqlstate": "23505", "http": 422, "grpc": "AlreadyExists"},
+//	 "ORA-20001": {"http": 404, "grpc": "NotFound"}}
+func LoadMappings(r io.Reader) error {
+	var mappings map[MsgID]Mapping
+	if err := json.NewDecoder(r).Decode(&mappings); err != nil {
+		return err
+	}
+	for id, m := range mappings {
+		if err := SetMapping(id, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadMappingsFile is LoadMappings from the file.
+func LoadMappingsFile(fileName string) error {
+	fh, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	if err := LoadMappings(fh); err != nil {
+		return fmt.Errorf("%s: %w", fileName, err)
+	}
+	return nil
+}
+
+// MapOf returns the mapping of the message: the built-in one
+// (by the message, or by its class, see Classify), overridden by SetMapping.
+func MapOf(id MsgID) Mapping {
+	m := defaultMapping
+	if bm, ok := builtinMappings[id.Code]; ok && id.Prefix == "ORA" {
+		m = bm
+	} else if c := Classify(id); c != 0 {
+		for _, cm := range classMappings {
+			if c&cm.Class != 0 {
+				m = cm.Mapping
+				break
+			}
+		}
+	}
+	registeredMappings.RLock()
+	defer registeredMappings.RUnlock()
+	return m.overlay(registeredMappings.m[id])
+}
+
+// MapError returns the mapping of the Oracle error in err (see FromError),
+// and false if there is none.
+func MapError(err error) (Mapping, bool) {
+	if oe := FromError(err); oe != nil {
+		return MapOf(oe.ID), true
+	}
+	return Mapping{}, false
+}
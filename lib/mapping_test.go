@@ -0,0 +1,88 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMapOf(t *testing.T) {
+	for _, tc := range []struct {
+		id   MsgID
+		want Mapping
+	}{
+		{MsgID{"ORA", 1}, Mapping{"23000", 409, GRPCAlreadyExists}},
+		{MsgID{"ORA", 1031}, Mapping{"42000", 403, GRPCPermissionDenied}},
+		{MsgID{"ORA", 3113}, Mapping{"08006", 503, GRPCUnavailable}},
+		{MsgID{"TNS", 12541}, Mapping{"08006", 503, GRPCUnavailable}},
+		{MsgID{"ORA", 1403}, Mapping{"02000", 404, GRPCNotFound}},
+		{MsgID{"ORA", 12899}, Mapping{"22001", 400, GRPCInvalidArgument}},
+		{MsgID{"ORA", 6512}, Mapping{"HY000", 500, GRPCInternal}},
+		{MsgID{"TNS", 1}, Mapping{"HY000", 500, GRPCInternal}},
+	} {
+		if got := MapOf(tc.id); got != tc.want {
+			t.Errorf("%s: got %v, wanted %v", tc.id, got, tc.want)
+		}
+	}
+	if m, ok := MapError(errors.New("insert: ORA-00001: unique constraint (A.B) violated")); !ok || m.HTTP != 409 {
+		t.Errorf("MapError: got %v, %t", m, ok)
+	}
+	if _, ok := MapError(errors.New("EOF")); ok {
+		t.Error("MapError(EOF): got a mapping")
+	}
+}
+
+func TestLoadMappings(t *testing.T) {
+	t.Cleanup(func() {
+		registeredMappings.Lock()
+		registeredMappings.m = nil
+		registeredMappings.Unlock()
+	})
+	if err := LoadMappings(strings.NewReader(`{
+		"ORA-00001": {"sqlstate": "23505", "http": 422},
+		"ora-20001": {"http": 404, "grpc": "NOT_FOUND"}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := MapOf(MsgID{"ORA", 1}), (Mapping{"23505", 422, GRPCAlreadyExists}); got != want {
+		t.Errorf("ORA-00001: got %v, wanted %v", got, want)
+	}
+	if got, want := MapOf(MsgID{"ORA", 20001}), (Mapping{"HY000", 404, GRPCNotFound}); got != want {
+		t.Errorf("ORA-20001: got %v, wanted %v", got, want)
+	}
+
+	for _, bad := range []string{
+		`{"ORA-00001": {"sqlstate": "235"}}`,
+		`{"ORA-00001": {"http": 42}}`,
+		`{"ORA-00001": {"grpc": "Nope"}}`,
+		`{"ORA-x": {}}`,
+	} {
+		if err := LoadMappings(strings.NewReader(bad)); err == nil {
+			t.Errorf("%s: wanted error", bad)
+		}
+	}
+
+	b, err := json.Marshal(MapOf(MsgID{"ORA", 1031}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"sqlstate":"42000","http":403,"grpc":"PermissionDenied"}`; got != want {
+		t.Errorf("JSON: got %s, wanted %s", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+// MemDB is an in-memory, read-only DB.
+type MemDB struct {
+	msgs []Message
+	meta map[string]string
+}
+
+var _ DB = (*MemDB)(nil)
+
+// NewMemDB collects the messages into a MemDB.
+func NewMemDB(meta map[string]string, msgs iter.Seq2[Message, error]) (*MemDB, error) {
+	db := MemDB{meta: meta}
+	for msg, err := range msgs {
+		if err != nil {
+			return nil, err
+		}
+		db.msgs = append(db.msgs, msg)
+	}
+	slices.SortStableFunc(db.msgs, func(a, b Message) int { return a.MsgID.Compare(b.MsgID) })
+	// the last of the duplicates wins
+	uniq := db.msgs[:0]
+	for i, msg := range db.msgs {
+		if i+1 < len(db.msgs) && db.msgs[i+1].MsgID == msg.MsgID {
+			continue
+		}
+		uniq = append(uniq, msg)
+	}
+	db.msgs = uniq
+	return &db, nil
+}
+
+// search returns the index of the first message not before id.
+func (db *MemDB) search(id MsgID) int {
+	i, _ := slices.BinarySearchFunc(db.msgs, id, func(msg Message, id MsgID) int { return msg.MsgID.Compare(id) })
+	return i
+}
+
+func (db *MemDB) Get(id MsgID) (MsgData, error) {
+	if i := db.search(id); i < len(db.msgs) && db.msgs[i].MsgID == id {
+		return db.msgs[i].MsgData, nil
+	}
+	return MsgData{}, ErrNotFound
+}
+
+func (db *MemDB) Meta() map[string]string { return db.meta }
+
+func (db *MemDB) All() iter.Seq2[Message, error] { return db.seq(0, len(db.msgs)) }
+
+func (db *MemDB) Prefix(prefix string) iter.Seq2[Message, error] {
+	prefix = strings.ToUpper(prefix)
+	i := db.search(MsgID{Prefix: prefix})
+	j := i
+	for j < len(db.msgs) && strings.HasPrefix(db.msgs[j].Prefix, prefix) {
+		j++
+	}
+	return db.seq(i, j)
+}
+
+func (db *MemDB) Range(from, to MsgID) iter.Seq2[Message, error] {
+	i, j := db.search(from), db.search(to)
+	if j < len(db.msgs) && db.msgs[j].MsgID == to {
+		j++
+	}
+	return db.seq(i, max(i, j))
+}
+
+func (db *MemDB) seq(i, j int) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		for _, msg := range db.msgs[i:j] {
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Close is a no-op.
+func (db *MemDB) Close() error { return nil }
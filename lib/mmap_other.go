@@ -0,0 +1,29 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+//go:build !unix
+
+package oerr
+
+import (
+	"io"
+	"os"
+)
+
+// mmapFile reads the whole file where mmap is not supported.
+func mmapFile(fh *os.File) ([]byte, func() error, error) {
+	data, err := io.ReadAll(fh)
+	return data, func() error { return nil }, err
+}
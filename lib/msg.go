@@ -16,9 +16,11 @@
 package oerr
 
 import (
+	"cmp"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 type MsgID struct {
@@ -29,6 +31,16 @@ type MsgID struct {
 func (id MsgID) String() string {
 	return fmt.Sprintf("%s-%05d", id.Prefix, id.Code)
 }
+
+// Compare returns -1, 0 or +1 as id is before, equal to or after other
+// in the order of the keys (MarshalBinary): by Prefix, then by Code.
+func (id MsgID) Compare(other MsgID) int {
+	if c := strings.Compare(id.Prefix, other.Prefix); c != 0 {
+		return c
+	}
+	return cmp.Compare(id.Code, other.Code)
+}
+
 func (id MsgID) MarshalBinary() (data []byte, err error) {
 	data = make([]byte, 3+4)
 	data[0], data[1], data[2] = id.Prefix[0], id.Prefix[1], id.Prefix[2]
@@ -81,3 +93,9 @@ type Message struct {
 	MsgID
 	MsgData
 }
+
+// IsApplicationError reports whether the id is in the ORA-20000..ORA-20999 range,
+// reserved for raise_application_error.
+func IsApplicationError(id MsgID) bool {
+	return id.Prefix == "ORA" && 20000 <= id.Code && id.Code <= 20999
+}
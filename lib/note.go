@@ -0,0 +1,181 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// notesBucketName holds the team notes, keyed by MsgID and a sequence number.
+const notesBucketName = "notes"
+
+// Note is a team annotation of a message.
+type Note struct {
+	MsgID  MsgID     `json:"-"`
+	Seq    uint64    `json:"-"`
+	Author string    `json:"author,omitempty"`
+	Time   time.Time `json:"time"`
+	Tags   []string  `json:"tags,omitempty"`
+	Text   string    `json:"text"`
+}
+
+func (n Note) String() string {
+	var extra string
+	if n.Author != "" {
+		extra = " " + n.Author
+	}
+	if len(n.Tags) != 0 {
+		extra += " [" + strings.Join(n.Tags, ",") + "]"
+	}
+	return fmt.Sprintf("#%d %s%s: %s", n.Seq, n.Time.Format("2006-01-02"), extra, n.Text)
+}
+
+// NoteLister is implemented by the DBs storing team notes.
+type NoteLister interface {
+	// Notes of the message, in the order of addition.
+	Notes(MsgID) ([]Note, error)
+	// AllNotes in MsgID order.
+	AllNotes() iter.Seq2[Note, error]
+}
+
+var _ NoteLister = (*dbS)(nil)
+
+func noteKey(id MsgID, seq uint64) ([]byte, error) {
+	key, err := id.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint64(key, seq), nil
+}
+
+func decodeNote(k, v []byte) (Note, error) {
+	var n Note
+	if err := n.MsgID.UnmarshalBinary(k); err != nil {
+		return n, err
+	}
+	if len(k) != 7+8 {
+		return n, fmt.Errorf("bad note key %q", k)
+	}
+	n.Seq = binary.BigEndian.Uint64(k[7:])
+	err := json.Unmarshal(v, &n)
+	return n, err
+}
+
+func (db *dbS) Notes(id MsgID) ([]Note, error) {
+	prefix, err := id.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var notes []Note
+	err = db.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(notesBucketName))
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			n, err := decodeNote(k, v)
+			if err != nil {
+				return err
+			}
+			notes = append(notes, n)
+		}
+		return nil
+	})
+	return notes, err
+}
+
+func (db *dbS) AllNotes() iter.Seq2[Note, error] {
+	return func(yield func(Note, error) bool) {
+		// read them all (they are few), to yield outside of the read lock
+		type noteErr struct {
+			Note
+			err error
+		}
+		var notes []noteErr
+		err := db.view(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket([]byte(notesBucketName))
+			if bucket == nil {
+				return nil
+			}
+			c := bucket.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				n, err := decodeNote(k, v)
+				notes = append(notes, noteErr{Note: n, err: err})
+			}
+			return nil
+		})
+		for _, n := range notes {
+			if !yield(n.Note, n.err) {
+				return
+			}
+		}
+		if err != nil {
+			yield(Note{}, err)
+		}
+	}
+}
+
+// AddNote stores the note, returning its sequence number.
+// Zero Time means now.
+func (w *Writer) AddNote(n Note) (uint64, error) {
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	val, err := json.Marshal(n)
+	if err != nil {
+		return 0, err
+	}
+	var seq uint64
+	err = w.update(func(bucket *bolt.Bucket) error {
+		notes, err := bucket.Tx().CreateBucketIfNotExists([]byte(notesBucketName))
+		if err != nil {
+			return err
+		}
+		if seq, err = notes.NextSequence(); err != nil {
+			return err
+		}
+		key, err := noteKey(n.MsgID, seq)
+		if err != nil {
+			return err
+		}
+		return notes.Put(key, val)
+	})
+	return seq, err
+}
+
+// DeleteNote deletes the note of the message with the given sequence number.
+func (w *Writer) DeleteNote(id MsgID, seq uint64) error {
+	key, err := noteKey(id, seq)
+	if err != nil {
+		return err
+	}
+	return w.update(func(bucket *bolt.Bucket) error {
+		notes := bucket.Tx().Bucket([]byte(notesBucketName))
+		if notes == nil || notes.Get(key) == nil {
+			return ErrNotFound
+		}
+		return notes.Delete(key)
+	})
+}
@@ -0,0 +1,485 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The packed format is an immutable, memory-mappable file:
+//
+//	header: magic, then the little-endian uint32 counts and section offsets of packHeader
+//	seeds:   [buckets]uint32, the minimal perfect hash displacements
+//	entries: [n]{prefix [3]byte, 0, code uint32, description, cause, action uint32}, in hash order
+//	order:   [n]uint32 entry indexes in (Prefix, Code) order
+//	texts:   [texts+1]uint32 offsets into textData
+//	textData: uvarint token indexes of each (deduplicated) text
+//	tokens:  [tokens+1]uint32 offsets into tokenData
+//	tokenData: the deduplicated tokens (words with their trailing space)
+//	meta:    uvarint(number of metadata), {uvarint(len(key)), key, uvarint(len(value)), value}...
+const (
+	packMagic       = "oerrpk\x00\x01"
+	packHeaderLen   = len(packMagic) + 13*4
+	packEntryLen    = 20
+	maxPackSeed     = 1 << 24
+	packBucketRatio = 3
+)
+
+type packHeader struct {
+	N, Buckets, Seeds, Entries, Order      uint32
+	Texts, NTexts, TextData                uint32
+	Tokens, NTokens, TokenData, Meta, Size uint32
+}
+
+func (h *packHeader) fields() []*uint32 {
+	return []*uint32{&h.N, &h.Buckets, &h.Seeds, &h.Entries, &h.Order,
+		&h.Texts, &h.NTexts, &h.TextData,
+		&h.Tokens, &h.NTokens, &h.TokenData, &h.Meta, &h.Size}
+}
+
+func packHash(key []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, c := range key {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	return h
+}
+
+// packMix is the splitmix64 finalizer of the hash and the seed.
+func packMix(h uint64, seed uint32) uint64 {
+	h ^= uint64(seed) * 0x9E3779B97F4A7C15
+	h ^= h >> 30
+	h *= 0xBF58476D1CE4E5B9
+	h ^= h >> 27
+	h *= 0x94D049BB133111EB
+	return h ^ h>>31
+}
+
+// WritePack writes the messages in the packed format, readable by OpenPack.
+func WritePack(w io.Writer, meta map[string]string, msgs iter.Seq2[Message, error]) error {
+	mdb, err := NewMemDB(meta, msgs)
+	if err != nil {
+		return err
+	}
+	sorted := mdb.msgs
+	n := len(sorted)
+	var hdr packHeader
+	hdr.N = uint32(n)
+	hdr.Buckets = uint32(n/packBucketRatio + 1)
+
+	// Hash and displace: place the biggest buckets first,
+	// searching for a seed which maps all their keys to free slots.
+	hashes := make([]uint64, n)
+	buckets := make([][]int, hdr.Buckets)
+	for i, msg := range sorted {
+		key, err := msg.MsgID.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		hashes[i] = packHash(key)
+		b := packMix(hashes[i], 0) % uint64(hdr.Buckets)
+		buckets[b] = append(buckets[b], i)
+	}
+	bucketOrder := make([]int, len(buckets))
+	for i := range bucketOrder {
+		bucketOrder[i] = i
+	}
+	sort.SliceStable(bucketOrder, func(i, j int) bool { return len(buckets[bucketOrder[i]]) > len(buckets[bucketOrder[j]]) })
+	seeds := make([]uint32, hdr.Buckets)
+	slotOf := make([]uint32, n)
+	taken := make([]bool, n)
+	slots := make([]uint32, 0, 16)
+	for _, b := range bucketOrder {
+		if len(buckets[b]) == 0 {
+			break
+		}
+	Seeds:
+		for seed := uint32(1); ; seed++ {
+			if seed == maxPackSeed {
+				return errors.New("cannot build the perfect hash")
+			}
+			slots = slots[:0]
+			for _, i := range buckets[b] {
+				slot := uint32(packMix(hashes[i], seed) % uint64(n))
+				if taken[slot] || slices.Contains(slots, slot) {
+					continue Seeds
+				}
+				slots = append(slots, slot)
+			}
+			seeds[b] = seed
+			for k, i := range buckets[b] {
+				taken[slots[k]] = true
+				slotOf[i] = slots[k]
+			}
+			break
+		}
+	}
+
+	texts, tokens := newStringTable(), newStringTable()
+	var textData []byte
+	textOffs := []uint32{0}
+	text := func(s string) uint32 {
+		i, ok := texts.index(s)
+		if !ok {
+			for _, tok := range strings.SplitAfter(s, " ") {
+				j, _ := tokens.index(tok)
+				textData = binary.AppendUvarint(textData, uint64(j))
+			}
+			textOffs = append(textOffs, uint32(len(textData)))
+		}
+		return i
+	}
+	text("")
+	entries := make([]byte, n*packEntryLen)
+	order := make([]byte, 0, n*4)
+	for i, msg := range sorted {
+		e := entries[int(slotOf[i])*packEntryLen:]
+		copy(e[:3], msg.Prefix)
+		binary.LittleEndian.PutUint32(e[4:], msg.Code)
+		binary.LittleEndian.PutUint32(e[8:], text(msg.Description))
+		binary.LittleEndian.PutUint32(e[12:], text(msg.Cause))
+		binary.LittleEndian.PutUint32(e[16:], text(msg.Action))
+		order = binary.LittleEndian.AppendUint32(order, slotOf[i])
+	}
+	var tokenData []byte
+	tokenOffs := []uint32{0}
+	for _, tok := range tokens.strings {
+		tokenData = append(tokenData, tok...)
+		tokenOffs = append(tokenOffs, uint32(len(tokenData)))
+	}
+	var metaData []byte
+	metaData = binary.AppendUvarint(metaData, uint64(len(meta)))
+	for _, k := range sortedKeys(meta) {
+		for _, s := range []string{k, meta[k]} {
+			metaData = binary.AppendUvarint(metaData, uint64(len(s)))
+			metaData = append(metaData, s...)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(packHeaderLen + 4*len(seeds) + len(entries) + len(order) + 4*len(textOffs) + len(textData) + 4*len(tokenOffs) + len(tokenData) + len(metaData))
+	buf.Write(make([]byte, packHeaderLen))
+	section := func(off *uint32, p []byte) {
+		*off = uint32(buf.Len())
+		buf.Write(p)
+	}
+	u32s := func(a []uint32) []byte {
+		p := make([]byte, 0, 4*len(a))
+		for _, u := range a {
+			p = binary.LittleEndian.AppendUint32(p, u)
+		}
+		return p
+	}
+	section(&hdr.Seeds, u32s(seeds))
+	section(&hdr.Entries, entries)
+	section(&hdr.Order, order)
+	hdr.NTexts = uint32(len(textOffs) - 1)
+	section(&hdr.Texts, u32s(textOffs))
+	section(&hdr.TextData, textData)
+	hdr.NTokens = uint32(len(tokenOffs) - 1)
+	section(&hdr.Tokens, u32s(tokenOffs))
+	section(&hdr.TokenData, tokenData)
+	section(&hdr.Meta, metaData)
+	hdr.Size = uint32(buf.Len())
+
+	p := buf.Bytes()
+	copy(p, packMagic)
+	for i, f := range hdr.fields() {
+		binary.LittleEndian.PutUint32(p[len(packMagic)+4*i:], *f)
+	}
+	_, err = w.Write(p)
+	return err
+}
+
+type stringTable struct {
+	strings []string
+	idx     map[string]uint32
+}
+
+func newStringTable() *stringTable { return &stringTable{idx: make(map[string]uint32)} }
+
+// index returns the index of s, adding it if it's new.
+func (t *stringTable) index(s string) (uint32, bool) {
+	if i, ok := t.idx[s]; ok {
+		return i, true
+	}
+	i := uint32(len(t.strings))
+	t.strings = append(t.strings, s)
+	t.idx[s] = i
+	return i, false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PackDB is a read-only DB of the packed format written by WritePack.
+// It is safe for concurrent use.
+type PackDB struct {
+	// mu guards data against the unmapping by Close
+	mu    sync.RWMutex
+	data  []byte
+	hdr   packHeader
+	meta  map[string]string
+	unmap func() error
+}
+
+var _ DB = (*PackDB)(nil)
+
+// ErrBadPack is returned for a corrupt or foreign packed file.
+var ErrBadPack = errors.New("bad packed file")
+
+// OpenPack memory-maps the packed file, where the platform supports it.
+func OpenPack(path string) (*PackDB, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, &OpenError{Path: path, Err: err}
+	}
+	defer fh.Close()
+	data, unmap, err := mmapFile(fh)
+	if err != nil {
+		return nil, &OpenError{Path: path, Err: err}
+	}
+	db, err := NewPackDB(data)
+	if err != nil {
+		unmap()
+		return nil, &OpenError{Path: path, Err: err}
+	}
+	db.unmap = unmap
+	return db, nil
+}
+
+// NewPackDB reads the packed format from the given bytes, without copying.
+func NewPackDB(data []byte) (*PackDB, error) {
+	if len(data) < packHeaderLen || string(data[:len(packMagic)]) != packMagic {
+		return nil, ErrBadPack
+	}
+	db := PackDB{data: data}
+	for i, f := range db.hdr.fields() {
+		*f = binary.LittleEndian.Uint32(data[len(packMagic)+4*i:])
+	}
+	if err := db.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadPack, err)
+	}
+	h := db.hdr
+	r := bytes.NewReader(data[h.Meta:])
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: meta: %v", ErrBadPack, err)
+	}
+	db.meta = make(map[string]string, n)
+	for ; n > 0; n-- {
+		var kv [2]string
+		for i := range kv {
+			length, err := binary.ReadUvarint(r)
+			if err != nil || length > uint64(r.Len()) {
+				return nil, fmt.Errorf("%w: meta", ErrBadPack)
+			}
+			p := make([]byte, length)
+			r.Read(p)
+			kv[i] = string(p)
+		}
+		db.meta[kv[0]] = kv[1]
+	}
+	return &db, nil
+}
+
+// validate the header and the offset tables, so the lookups cannot go out of bounds.
+func (db *PackDB) validate() error {
+	h := db.hdr
+	// in uint64, to not overflow
+	u := func(x uint32) uint64 { return uint64(x) }
+	if u(h.Size) != uint64(len(db.data)) || u(h.Seeds) != uint64(packHeaderLen) ||
+		u(h.Seeds)+4*u(h.Buckets) != u(h.Entries) || u(h.Entries)+packEntryLen*u(h.N) != u(h.Order) ||
+		u(h.Order)+4*u(h.N) != u(h.Texts) || u(h.Texts)+4*(u(h.NTexts)+1) != u(h.TextData) ||
+		h.TextData > h.Tokens ||
+		u(h.Tokens)+4*(u(h.NTokens)+1) != u(h.TokenData) || h.TokenData > h.Meta || h.Meta >= h.Size ||
+		h.N != 0 && h.Buckets == 0 {
+		return errors.New("inconsistent header")
+	}
+	// the offsets must be ascending, within their data
+	offsets := func(name string, table, n, size uint32) error {
+		var prev uint32
+		for i := uint32(0); i <= n; i++ {
+			off := binary.LittleEndian.Uint32(db.data[table+4*i:])
+			if off < prev || off > size {
+				return fmt.Errorf("%s offset %d: %d", name, i, off)
+			}
+			prev = off
+		}
+		return nil
+	}
+	if err := offsets("text", h.Texts, h.NTexts, h.Tokens-h.TextData); err != nil {
+		return err
+	}
+	if err := offsets("token", h.Tokens, h.NTokens, h.Meta-h.TokenData); err != nil {
+		return err
+	}
+	for i := uint32(0); i < h.N; i++ {
+		if j := binary.LittleEndian.Uint32(db.data[h.Order+4*i:]); j >= h.N {
+			return fmt.Errorf("order %d: %d", i, j)
+		}
+	}
+	return nil
+}
+
+func (db *PackDB) u32(off uint32) uint32 { return binary.LittleEndian.Uint32(db.data[off:]) }
+
+func (db *PackDB) entry(i uint32) []byte {
+	off := db.hdr.Entries + i*packEntryLen
+	return db.data[off : off+packEntryLen]
+}
+
+func (db *PackDB) entryID(e []byte) MsgID {
+	return MsgID{Prefix: string(e[:3]), Code: binary.LittleEndian.Uint32(e[4:])}
+}
+
+func (db *PackDB) text(i uint32) string {
+	if i == 0 || i >= db.hdr.NTexts {
+		return ""
+	}
+	start, end := db.u32(db.hdr.Texts+4*i), db.u32(db.hdr.Texts+4*i+4)
+	p := db.data[db.hdr.TextData+start : db.hdr.TextData+end]
+	var sb strings.Builder
+	for len(p) != 0 {
+		tok, n := binary.Uvarint(p)
+		if n <= 0 || tok >= uint64(db.hdr.NTokens) {
+			break
+		}
+		p = p[n:]
+		off := db.hdr.Tokens + 4*uint32(tok)
+		sb.Write(db.data[db.hdr.TokenData+db.u32(off) : db.hdr.TokenData+db.u32(off+4)])
+	}
+	return sb.String()
+}
+
+func (db *PackDB) message(e []byte) Message {
+	return Message{
+		MsgID: db.entryID(e),
+		MsgData: MsgData{
+			Description: db.text(binary.LittleEndian.Uint32(e[8:])),
+			Cause:       db.text(binary.LittleEndian.Uint32(e[12:])),
+			Action:      db.text(binary.LittleEndian.Uint32(e[16:])),
+		},
+	}
+}
+
+func (db *PackDB) Get(id MsgID) (MsgData, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.data == nil {
+		return MsgData{}, ErrClosed
+	}
+	if db.hdr.N == 0 {
+		return MsgData{}, ErrNotFound
+	}
+	key, err := id.MarshalBinary()
+	if err != nil {
+		return MsgData{}, err
+	}
+	h := packHash(key)
+	seed := db.u32(db.hdr.Seeds + 4*uint32(packMix(h, 0)%uint64(db.hdr.Buckets)))
+	e := db.entry(uint32(packMix(h, seed) % uint64(db.hdr.N)))
+	if !bytes.Equal(e[:3], key[:3]) || binary.LittleEndian.Uint32(e[4:]) != id.Code {
+		return MsgData{}, ErrNotFound
+	}
+	return db.message(e).MsgData, nil
+}
+
+func (db *PackDB) Meta() map[string]string { return db.meta }
+
+// search returns the position in the order of the first message not before id.
+func (db *PackDB) search(id MsgID) uint32 {
+	return uint32(sort.Search(int(db.hdr.N), func(i int) bool {
+		return db.entryID(db.entry(db.u32(db.hdr.Order+4*uint32(i)))).Compare(id) >= 0
+	}))
+}
+
+func (db *PackDB) All() iter.Seq2[Message, error] {
+	return db.seq(nil, func(MsgID) bool { return true })
+}
+
+func (db *PackDB) Prefix(prefix string) iter.Seq2[Message, error] {
+	prefix = strings.ToUpper(prefix)
+	return db.seq(&MsgID{Prefix: prefix}, func(id MsgID) bool { return strings.HasPrefix(id.Prefix, prefix) })
+}
+
+func (db *PackDB) Range(from, to MsgID) iter.Seq2[Message, error] {
+	return db.seq(&from, func(id MsgID) bool { return id.Compare(to) <= 0 })
+}
+
+// seq yields the messages in order from the first not before from (or the first one)
+// till ok returns false, each read under the read lock, but yielded outside of it.
+func (db *PackDB) seq(from *MsgID, ok func(MsgID) bool) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		var start uint32
+		if from != nil {
+			db.mu.RLock()
+			if db.data != nil {
+				start = db.search(*from)
+			}
+			db.mu.RUnlock()
+		}
+		for i := start; i < db.hdr.N; i++ {
+			db.mu.RLock()
+			if db.data == nil {
+				db.mu.RUnlock()
+				yield(Message{}, ErrClosed)
+				return
+			}
+			e := db.entry(db.u32(db.hdr.Order + 4*i))
+			id := db.entryID(e)
+			found := ok(id)
+			var msg Message
+			if found {
+				msg = db.message(e)
+			}
+			db.mu.RUnlock()
+			if !found || !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Close unmaps the file, after the running lookups finish.
+// The later lookups return ErrClosed.
+func (db *PackDB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	unmap := db.unmap
+	db.data, db.unmap = nil, nil
+	if unmap == nil {
+		return nil
+	}
+	return unmap()
+}
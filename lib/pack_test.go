@@ -0,0 +1,214 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// benchMsgs returns n messages, roughly the size of the real catalog's.
+func benchMsgs(n int) []Message {
+	prefixes := []string{"ORA", "PLS", "TNS", "IMP", "EXP"}
+	msgs := make([]Message, n)
+	for i := range msgs {
+		msgs[i] = Message{
+			MsgID{prefixes[i%len(prefixes)], uint32(i * 7)},
+			MsgData{
+				Description: fmt.Sprintf("message number %d of string string", i),
+				Cause:       fmt.Sprintf("The %d. thing happened, as the string was too long.", i%100),
+				Action:      "Contact Oracle Support Services.",
+			},
+		}
+	}
+	return msgs
+}
+
+func writeTestPack(t testing.TB, msgs []Message) string {
+	fn := filepath.Join(t.TempDir(), "oerr.pack")
+	fh, err := os.Create(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePack(fh, map[string]string{MetaURL: URL}, seqOf(msgs...)); err != nil {
+		t.Fatal(err)
+	}
+	if err := fh.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestPack(t *testing.T) {
+	msgs := append(benchMsgs(1000), testMsgs...)
+	db, err := OpenPack(writeTestPack(t, msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, msg := range msgs {
+		if data, err := db.Get(msg.MsgID); err != nil || data != msg.MsgData {
+			t.Errorf("Get(%s): got %v, %v; wanted %v", msg.MsgID, data, err, msg.MsgData)
+		}
+	}
+	if _, err := db.Get(MsgID{"ORA", 2}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(ORA-00002): got %v", err)
+	}
+	if db.Meta()[MetaURL] != URL {
+		t.Errorf("meta: got %v", db.Meta())
+	}
+	if n := len(collect(t, db.All())); n != len(msgs) {
+		t.Errorf("All: got %d, wanted %d", n, len(msgs))
+	}
+	if got := collect(t, db.Range(MsgID{"ORA", 1500}, MsgID{"ORA", 1600})); len(got) != 3+3 {
+		t.Errorf("Range: got %v", got)
+	}
+
+	empty, err := OpenPack(writeTestPack(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer empty.Close()
+	if _, err := empty.Get(MsgID{"ORA", 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get from empty: got %v", err)
+	}
+}
+
+func BenchmarkPackGet(b *testing.B) {
+	msgs := benchMsgs(10000)
+	db, err := OpenPack(writeTestPack(b, msgs))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.Get(msgs[i%len(msgs)].MsgID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBoltGet(b *testing.B) {
+	msgs := benchMsgs(10000)
+	db, err := Open(newTestDB(b, msgs))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.Get(msgs[i%len(msgs)].MsgID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOpenPack(b *testing.B) {
+	fn := writeTestPack(b, benchMsgs(10000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db, err := OpenPack(fn)
+		if err != nil {
+			b.Fatal(err)
+		}
+		db.Close()
+	}
+}
+
+func BenchmarkOpenBolt(b *testing.B) {
+	fn := newTestDB(b, benchMsgs(10000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db, err := Open(fn)
+		if err != nil {
+			b.Fatal(err)
+		}
+		db.Close()
+	}
+}
+
+func TestPackCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePack(&buf, map[string]string{MetaURL: URL}, seqOf(testMsgs...)); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	use := func(name string, data []byte) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("%s: panic: %v", name, r)
+			}
+		}()
+		db, err := NewPackDB(data)
+		if err != nil {
+			if !errors.Is(err, ErrBadPack) {
+				t.Errorf("%s: got %v", name, err)
+			}
+			return
+		}
+		for _, msg := range testMsgs {
+			db.Get(msg.MsgID)
+		}
+		for range db.All() {
+		}
+	}
+	for n := 0; n < len(good); n += 7 {
+		use(fmt.Sprintf("truncated at %d", n), good[:n])
+	}
+	for i := len(packMagic); i < len(good); i++ {
+		for _, b := range []byte{0, 0x7f, 0xff} {
+			data := bytes.Clone(good)
+			data[i] = b
+			use(fmt.Sprintf("byte %d=%#x", i, b), data)
+		}
+	}
+}
+
+func TestPackClose(t *testing.T) {
+	db, err := OpenPack(writeTestPack(t, benchMsgs(1000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := db.Get(MsgID{"ORA", 7}); errors.Is(err, ErrClosed) {
+					return
+				}
+				for _, err := range db.Prefix("PLS") {
+					if err != nil {
+						break
+					}
+				}
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,174 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrBadMsgID is returned for unparseable message IDs.
+var ErrBadMsgID = errors.New("bad message id")
+
+// AmbiguousError is returned by ResolveMsgID for a bare number existing under several prefixes.
+type AmbiguousError struct {
+	Code uint32
+	IDs  []MsgID
+}
+
+func (e *AmbiguousError) Error() string {
+	ids := make([]string, len(e.IDs))
+	for i, id := range e.IDs {
+		ids[i] = id.String()
+	}
+	return fmt.Sprintf("%d is ambiguous: %s", e.Code, strings.Join(ids, ", "))
+}
+
+// ParseMsgID parses the message ID, such as ORA-00001, ora-1, "ORA 1", TNS-12154 or PLS-00201.
+// A bare number (1403) and a negative SQLCODE (-1) are ORA codes; see ResolveMsgID.
+func ParseMsgID(txt string) (MsgID, error) {
+	id, _, err := parseMsgID(txt)
+	return id, err
+}
+
+// parseMsgID returns whether the ID has an explicit prefix.
+func parseMsgID(txt string) (MsgID, bool, error) {
+	id := MsgID{Prefix: "ORA"}
+	s := strings.TrimSuffix(strings.TrimSpace(txt), ":")
+	i := strings.IndexFunc(s, func(r rune) bool { return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') })
+	if i < 0 {
+		i = len(s)
+	}
+	hasPrefix := i != 0
+	if hasPrefix {
+		if i != 3 {
+			return id, false, fmt.Errorf("%w %q: the prefix must be three letters", ErrBadMsgID, txt)
+		}
+		id.Prefix = strings.ToUpper(s[:i])
+		s = strings.TrimLeft(s[i:], " -_")
+	} else if strings.HasPrefix(s, "-") {
+		// SQLCODE
+		s = s[1:]
+	}
+	if s == "" || s[0] < '0' || '9' < s[0] {
+		return id, false, fmt.Errorf("%w %q: no code", ErrBadMsgID, txt)
+	}
+	code, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return id, false, fmt.Errorf("%w %q: %w", ErrBadMsgID, txt, err)
+	}
+	if code > MaxCode {
+		return id, false, fmt.Errorf("%w %q: the code is bigger than %d", ErrBadMsgID, txt, MaxCode)
+	}
+	id.Code = uint32(code)
+	return id, hasPrefix, nil
+}
+
+// ResolveMsgID parses the ID as ParseMsgID, but a bare number is looked up under all the prefixes
+// of the DB, returning an *AmbiguousError if it exists under several of them.
+// The explicit ORA of a negative SQLCODE is not ambiguous.
+func ResolveMsgID(db DB, txt string) (MsgID, error) {
+	id, hasPrefix, err := parseMsgID(txt)
+	if err != nil || hasPrefix || strings.HasPrefix(strings.TrimSpace(txt), "-") {
+		return id, err
+	}
+	prefixes, err := Prefixes(db)
+	if err != nil {
+		return id, err
+	}
+	var found []MsgID
+	for _, p := range prefixes {
+		cand := MsgID{Prefix: p, Code: id.Code}
+		if _, err := db.Get(cand); err == nil {
+			found = append(found, cand)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return id, nil
+	case 1:
+		return found[0], nil
+	}
+	return id, &AmbiguousError{Code: id.Code, IDs: found}
+}
+
+// Prefixes returns the prefixes in the DB, in order,
+// seeking from prefix to prefix with Range.
+func Prefixes(db Lister) ([]string, error) {
+	var prefixes []string
+	from := MsgID{Prefix: "\x00\x00\x00"}
+	for {
+		var next string
+		for msg, err := range db.Range(from, MaxID("\xff\xff\xff")) {
+			if err != nil {
+				return prefixes, err
+			}
+			next = msg.Prefix
+			break
+		}
+		if next == "" {
+			return prefixes, nil
+		}
+		prefixes = append(prefixes, next)
+		// the next possible prefix
+		b := []byte(next)
+		i := len(b) - 1
+		for ; i >= 0 && b[i] == 0xff; i-- {
+			b[i] = 0
+		}
+		if i < 0 {
+			return prefixes, nil
+		}
+		b[i]++
+		from = MsgID{Prefix: string(b)}
+	}
+}
+
+// MarshalText returns the ID as ORA-00001.
+func (id MsgID) MarshalText() ([]byte, error) { return []byte(id.String()), nil }
+
+// UnmarshalText parses the ID with ParseMsgID.
+func (id *MsgID) UnmarshalText(text []byte) error {
+	var err error
+	*id, err = ParseMsgID(string(text))
+	return err
+}
+
+// message is the JSON form of Message: its fields flattened,
+// not the text of the embedded MsgID.
+type message struct {
+	Prefix                     string
+	Code                       uint32
+	Description, Cause, Action string
+}
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(message{Prefix: m.Prefix, Code: m.Code,
+		Description: m.Description, Cause: m.Cause, Action: m.Action})
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*m = Message{MsgID: MsgID{Prefix: msg.Prefix, Code: msg.Code},
+		MsgData: MsgData{Description: msg.Description, Cause: msg.Cause, Action: msg.Action}}
+	return nil
+}
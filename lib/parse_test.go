@@ -0,0 +1,131 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseMsgID(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want MsgID
+	}{
+		{"ORA-00001", MsgID{"ORA", 1}},
+		{"ora-1", MsgID{"ORA", 1}},
+		{"ORA 1", MsgID{"ORA", 1}},
+		{" ORA-01555: ", MsgID{"ORA", 1555}},
+		{"-1", MsgID{"ORA", 1}},
+		{"1403", MsgID{"ORA", 1403}},
+		{"TNS-12154", MsgID{"TNS", 12154}},
+		{"PLS-00201", MsgID{"PLS", 201}},
+	} {
+		got, err := ParseMsgID(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("%q: got %v, %v, wanted %v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "ORA-", "ORA", "OR-1", "RMAN-06054", "ORA-1x", "ORA-100000", "x"} {
+		if got, err := ParseMsgID(in); !errors.Is(err, ErrBadMsgID) {
+			t.Errorf("%q: got %v, %v, wanted ErrBadMsgID", in, got, err)
+		}
+	}
+}
+
+func TestResolveMsgID(t *testing.T) {
+	msgs := append([]Message{
+		{MsgID{"PLS", 1}, MsgData{Description: "the PLS one"}},
+	}, testMsgs...)
+	mdb, err := NewMemDB(nil, seqOf(msgs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb, err := Open(newTestDB(t, msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bdb.Close()
+	for _, db := range []DB{mdb, bdb, &Layered{Layers: []Layer{{Name: "pack", DB: openTestPack(t, msgs)}}}} {
+		if prefixes, err := Prefixes(db); err != nil || fmt.Sprint(prefixes) != "[ORA PLS TNS]" {
+			t.Errorf("%T: Prefixes: got %v, %v", db, prefixes, err)
+		}
+		for in, want := range map[string]string{
+			"12154":     "TNS-12154",
+			"201":       "PLS-00201",
+			"1555":      "ORA-01555",
+			"-1":        "ORA-00001",
+			"ORA-1":     "ORA-00001",
+			"42":        "ORA-00042",
+			"PLS-00001": "PLS-00001",
+		} {
+			if got, err := ResolveMsgID(db, in); err != nil || got.String() != want {
+				t.Errorf("%T: %q: got %v, %v, wanted %s", db, in, got, err, want)
+			}
+		}
+		var ae *AmbiguousError
+		if _, err := ResolveMsgID(db, "1"); !errors.As(err, &ae) || len(ae.IDs) != 2 {
+			t.Errorf("%T: 1: got %v, wanted AmbiguousError", db, err)
+		} else {
+			t.Log(err)
+		}
+	}
+}
+
+func openTestPack(t *testing.T, msgs []Message) DB {
+	db, err := OpenPack(writeTestPack(t, msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMsgIDText(t *testing.T) {
+	var v struct {
+		ID  MsgID
+		Msg Message
+	}
+	if err := json.Unmarshal([]byte(`{"ID":"ora-60","Msg":{"Prefix":"PLS","Code":201,"Description":"d"}}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != (MsgID{"ORA", 60}) || v.Msg.MsgID != (MsgID{"PLS", 201}) || v.Msg.Description != "d" {
+		t.Errorf("got %+v", v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"ID":"ORA-00060","Msg":{"Prefix":"PLS","Code":201,"Description":"d","Cause":"","Action":""}}`; string(b) != want {
+		t.Errorf("got %s, wanted %s", b, want)
+	}
+}
+
+func TestMsgIDCompare(t *testing.T) {
+	ids := []MsgID{{"ORA", 1}, {"ORA", 60}, {"ORA", 256}, {"ORA", 1 << 24}, {"PLS", 0}, {"TNS", 12154}}
+	for _, a := range ids {
+		for _, b := range ids {
+			ka, _ := a.MarshalBinary()
+			kb, _ := b.MarshalBinary()
+			if got, want := a.Compare(b), bytes.Compare(ka, kb); got != want {
+				t.Errorf("%s.Compare(%s): got %d, wanted %d (the key order)", a, b, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bytes"
+	"iter"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// overridesBucketName holds the custom and patched messages.
+// DownloadInto replaces only the downloaded messages, keeping this bucket.
+const overridesBucketName = "overrides"
+
+// tombstone marks a deleted message in the overrides bucket.
+var tombstone = []byte{0}
+
+func isTombstone(val []byte) bool { return bytes.Equal(val, tombstone) }
+
+// Writer modifies the overrides of the DB.
+// It reads like the DB returned by Open, with the overrides applied.
+type Writer struct {
+	*dbS
+}
+
+// OpenWriter opens the DB for writing, creating it if it does not exist.
+func OpenWriter(dbPath string, opts Options) (*Writer, error) {
+	db, err := bolt.Open(dbPath, 0664, &bolt.Options{Timeout: opts.Timeout})
+	if err != nil {
+		if err == bolt.ErrTimeout {
+			err = ErrTimeout
+		}
+		return nil, &OpenError{Path: dbPath, Err: err}
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(overridesBucketName))
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, &OpenError{Path: dbPath, Err: err}
+	}
+	return &Writer{dbS: &dbS{db: db}}, nil
+}
+
+// update calls f with the overrides bucket in a new read-write transaction.
+func (w *Writer) update(f func(bucket *bolt.Bucket) error) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.db == nil {
+		return ErrClosed
+	}
+	return w.db.Update(func(tx *bolt.Tx) error {
+		return f(tx.Bucket([]byte(overridesBucketName)))
+	})
+}
+
+// Put the message into the overrides, shadowing the downloaded one with the same MsgID.
+func (w *Writer) Put(msg Message) error {
+	return w.update(func(bucket *bolt.Bucket) error { return put(bucket, msg) })
+}
+
+func put(bucket *bolt.Bucket, msg Message) error {
+	key, err := msg.MsgID.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	val, err := msg.MsgData.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, val)
+}
+
+// Load puts all the messages in one transaction, returning the number of messages put.
+// The first error stops the load, and rolls back the transaction.
+func (w *Writer) Load(msgs iter.Seq2[Message, error]) (int, error) {
+	var n int
+	err := w.update(func(bucket *bolt.Bucket) error {
+		for msg, err := range msgs {
+			if err != nil {
+				return err
+			}
+			if err = put(bucket, msg); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Delete the message: the override is removed, and the downloaded message is hidden.
+func (w *Writer) Delete(id MsgID) error {
+	key, err := id.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return w.update(func(bucket *bolt.Bucket) error {
+		if base := bucket.Tx().Bucket([]byte(bucketName)); base != nil && base.Get(key) != nil {
+			return bucket.Put(key, tombstone)
+		}
+		if bucket.Get(key) == nil {
+			return ErrNotFound
+		}
+		return bucket.Delete(key)
+	})
+}
+
+// Revert removes the override (or deletion) of the message, so the downloaded one is visible again.
+func (w *Writer) Revert(id MsgID) error {
+	key, err := id.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return w.update(func(bucket *bolt.Bucket) error {
+		if bucket.Get(key) == nil {
+			return ErrNotFound
+		}
+		return bucket.Delete(key)
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	dbPath := newTestDB(t, testMsgs)
+	w, err := OpenWriter(dbPath, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := Message{MsgID{"ORA", 20001}, MsgData{Description: "order not found"}}
+	patched := Message{MsgID{"ORA", 60}, MsgData{Description: "deadlock detected", Cause: "see the trace file"}}
+	if n, err := w.Load(seqOf(custom, patched)); err != nil || n != 2 {
+		t.Fatalf("Load: %d, %v", n, err)
+	}
+	if err := w.Delete(MsgID{"ORA", 1555}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if data, err := db.Get(patched.MsgID); err != nil || data != patched.MsgData {
+		t.Errorf("Get(%s): got %v, %v", patched.MsgID, data, err)
+	}
+	if _, err := db.Get(MsgID{"ORA", 1555}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get deleted: got %v", err)
+	}
+	var ids []MsgID
+	for _, msg := range collect(t, db.Range(MsgID{"ORA", 0}, MaxID("ORA"))) {
+		ids = append(ids, msg.MsgID)
+	}
+	want := []MsgID{{"ORA", 1}, {"ORA", 60}, {"ORA", 1500}, {"ORA", 1600}, {"ORA", 20001}}
+	if !slices.Equal(ids, want) {
+		t.Errorf("got %v, wanted %v", ids, want)
+	}
+}
+
+func TestNotes(t *testing.T) {
+	dbPath := newTestDB(t, testMsgs)
+	w, err := OpenWriter(dbPath, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := MsgID{"ORA", 4068}
+	for _, txt := range []string{"after deploy: recompile package X", "or just retry"} {
+		if _, err := w.AddNote(Note{MsgID: id, Author: "ops", Tags: []string{"deploy"}, Text: txt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := w.AddNote(Note{MsgID: MsgID{"ORA", 60}, Text: "check the trace"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.DeleteNote(id, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	notes, err := db.(NoteLister).Notes(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].Seq != 1 || notes[0].Author != "ops" || notes[0].Text != "after deploy: recompile package X" {
+		t.Errorf("got %v", notes)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"iter"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ReloadOptions for OpenReloading.
+type ReloadOptions struct {
+	Options
+	// Interval of checking the file for changes (inode, size, mtime).
+	// Zero disables polling: only explicit Reload calls reopen the file.
+	Interval time.Duration
+	// OnReload is called after each reload attempt, with a nil error on success.
+	// If nil, the attempts are logged.
+	OnReload func(path string, err error)
+}
+
+// ReloadingDB is a DB which reopens its file when it is replaced.
+//
+// New lookups use the new file as soon as it is opened,
+// the old one is closed after its in-flight lookups finish.
+type ReloadingDB struct {
+	path string
+	opts ReloadOptions
+
+	mu  sync.RWMutex
+	cur *generation
+	fi  os.FileInfo
+
+	reloadMu sync.Mutex
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type generation struct {
+	*dbS
+	// inFlight lookups are waited for by Close, the iterations are not:
+	// they may call Close, and get ErrClosed for the rest.
+	inFlight, iters sync.WaitGroup
+}
+
+var (
+	_ DB         = (*ReloadingDB)(nil)
+	_ NoteLister = (*ReloadingDB)(nil)
+)
+
+// OpenReloading opens the DB, and watches its file for changes if opts.Interval is not zero.
+func OpenReloading(dbPath string, opts ReloadOptions) (*ReloadingDB, error) {
+	r := &ReloadingDB{path: dbPath, opts: opts}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	if opts.Interval > 0 {
+		r.stop, r.done = make(chan struct{}), make(chan struct{})
+		go r.watch()
+	}
+	return r, nil
+}
+
+func (r *ReloadingDB) watch() {
+	defer close(r.done)
+	t := time.NewTicker(r.opts.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-t.C:
+		}
+		r.mu.RLock()
+		old := r.fi
+		r.mu.RUnlock()
+		fi, err := os.Stat(r.path)
+		if err != nil || sameFile(old, fi) {
+			continue
+		}
+		r.Reload()
+	}
+}
+
+func sameFile(a, b os.FileInfo) bool {
+	return a != nil && b != nil && os.SameFile(a, b) &&
+		a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// Reload opens the file again, and swaps it in for the new lookups.
+// On error, the old DB is kept.
+func (r *ReloadingDB) Reload() error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+	initial := r.cur == nil
+	err := r.reload()
+	if !initial {
+		if r.opts.OnReload != nil {
+			r.opts.OnReload(r.path, err)
+		} else if err != nil {
+			log.Printf("reload %q: %v", r.path, err)
+		} else {
+			log.Printf("reloaded %q", r.path)
+		}
+	}
+	return err
+}
+
+func (r *ReloadingDB) reload() error {
+	fi, err := os.Stat(r.path)
+	if err != nil {
+		return &OpenError{Path: r.path, Err: err}
+	}
+	db, err := OpenOptions(r.path, r.opts.Options)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	old := r.cur
+	r.cur, r.fi = &generation{dbS: db.(*dbS)}, fi
+	r.mu.Unlock()
+	if old != nil {
+		go func() {
+			old.inFlight.Wait()
+			old.iters.Wait()
+			old.Close()
+		}()
+	}
+	return nil
+}
+
+// acquire the current generation, which must be released with inFlight.Done.
+func (r *ReloadingDB) acquire() (*generation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cur == nil {
+		return nil, ErrClosed
+	}
+	r.cur.inFlight.Add(1)
+	return r.cur, nil
+}
+
+// acquireIter acquires the current generation for an iteration,
+// which must be released with iters.Done.
+func (r *ReloadingDB) acquireIter() (*generation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cur == nil {
+		return nil, ErrClosed
+	}
+	r.cur.iters.Add(1)
+	return r.cur, nil
+}
+
+func (r *ReloadingDB) Get(id MsgID) (MsgData, error) {
+	g, err := r.acquire()
+	if err != nil {
+		return MsgData{}, err
+	}
+	defer g.inFlight.Done()
+	return g.Get(id)
+}
+
+func (r *ReloadingDB) Meta() map[string]string {
+	g, err := r.acquire()
+	if err != nil {
+		return nil
+	}
+	defer g.inFlight.Done()
+	return g.Meta()
+}
+
+func (r *ReloadingDB) Provenance(id MsgID) Provenance {
+	g, err := r.acquire()
+	if err != nil {
+		return Provenance{}
+	}
+	defer g.inFlight.Done()
+	return g.Provenance(id)
+}
+
+func (r *ReloadingDB) Notes(id MsgID) ([]Note, error) {
+	g, err := r.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer g.inFlight.Done()
+	return g.Notes(id)
+}
+
+func (r *ReloadingDB) AllNotes() iter.Seq2[Note, error] {
+	return func(yield func(Note, error) bool) {
+		g, err := r.acquireIter()
+		if err != nil {
+			yield(Note{}, err)
+			return
+		}
+		defer g.iters.Done()
+		g.AllNotes()(yield)
+	}
+}
+
+func (r *ReloadingDB) All() iter.Seq2[Message, error] {
+	return r.seq(func(db DB) iter.Seq2[Message, error] { return db.All() })
+}
+
+func (r *ReloadingDB) Prefix(prefix string) iter.Seq2[Message, error] {
+	return r.seq(func(db DB) iter.Seq2[Message, error] { return db.Prefix(prefix) })
+}
+
+func (r *ReloadingDB) Range(from, to MsgID) iter.Seq2[Message, error] {
+	return r.seq(func(db DB) iter.Seq2[Message, error] { return db.Range(from, to) })
+}
+
+// seq iterates over one generation, even if the file is reloaded meanwhile.
+func (r *ReloadingDB) seq(f func(DB) iter.Seq2[Message, error]) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		g, err := r.acquireIter()
+		if err != nil {
+			yield(Message{}, err)
+			return
+		}
+		defer g.iters.Done()
+		f(g.dbS)(yield)
+	}
+}
+
+// Close stops watching, and closes the DB after the in-flight lookups finish.
+// The running iterations get ErrClosed for their rest.
+func (r *ReloadingDB) Close() error {
+	r.stopOnce.Do(func() {
+		if r.stop != nil {
+			close(r.stop)
+			<-r.done
+		}
+	})
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+	r.mu.Lock()
+	g := r.cur
+	r.cur = nil
+	r.mu.Unlock()
+	if g == nil {
+		return nil
+	}
+	g.inFlight.Wait()
+	return g.Close()
+}
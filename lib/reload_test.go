@@ -0,0 +1,108 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"iter"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	dbPath := newTestDB(t, testMsgs[:2])
+	reloaded := make(chan error, 1)
+	db, err := OpenReloading(dbPath, ReloadOptions{
+		Interval: 10 * time.Millisecond,
+		OnReload: func(_ string, err error) { reloaded <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	id := testMsgs[len(testMsgs)-1].MsgID
+	if _, err := db.Get(id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(%s) before reload: got %v, wanted ErrNotFound", id, err)
+	}
+
+	// A long iteration must survive the reload.
+	next, stop := iter.Pull2(db.All())
+	defer stop()
+	next()
+
+	if err := os.Rename(newTestDB(t, testMsgs), dbPath); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload")
+	}
+	if _, err := db.Get(id); err != nil {
+		t.Errorf("Get(%s) after reload: %v", id, err)
+	}
+	if msg, err, ok := next(); !ok || err != nil || msg.MsgID != testMsgs[1].MsgID {
+		t.Errorf("iteration over the old DB: got %v, %v, %t", msg, err, ok)
+	}
+}
+
+func TestReloadCloseInLoop(t *testing.T) {
+	msgs := make([]Message, 2*seekBatch)
+	for i := range msgs {
+		msgs[i] = Message{MsgID{"ORA", uint32(i + 1)}, MsgData{Description: "x"}}
+	}
+	db, err := OpenReloading(newTestDB(t, msgs), ReloadOptions{Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		var n int
+		for _, err := range db.All() {
+			if err != nil {
+				done <- err
+				return
+			}
+			if n++; n == 1 {
+				db.Close()
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, ErrClosed) {
+			t.Errorf("iteration: %+v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Close in the loop: deadlock")
+	}
+	// Close again, concurrently
+	errc := make(chan error, 2)
+	for range 2 {
+		go func() { errc <- db.Close() }()
+	}
+	for range 2 {
+		if err := <-errc; err != nil {
+			t.Errorf("second Close: %v", err)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// Package retry retries database operations failing with retryable Oracle errors,
+// as classified by oerr.Classify.
+package retry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+// Action is the decision about a failed attempt.
+type Action uint8
+
+const (
+	// Stop returns the error.
+	Stop = Action(iota)
+	// Again retries in the same session (after a rollback, by the function itself).
+	Again
+	// Reconnect retries on a new connection.
+	Reconnect
+)
+
+func (a Action) String() string {
+	switch a {
+	case Again:
+		return "again"
+	case Reconnect:
+		return "reconnect"
+	}
+	return "stop"
+}
+
+// Decide the Action for the error:
+//
+//   - Reconnect for connection errors (ClassConnection, driver.ErrBadConn, sql.ErrConnDone),
+//   - Again for retryable and transient errors,
+//   - Stop for anything else, including the context errors.
+func Decide(err error) Action {
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return Stop
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone),
+		oerr.IsConnectionError(err):
+		return Reconnect
+	case oerr.IsRetryable(err):
+		return Again
+	}
+	return Stop
+}
+
+// Attempt is a failed attempt, passed to the hooks.
+type Attempt struct {
+	// N is the 1-based number of the attempt.
+	N   int
+	Err error
+	// ID and Class of the Oracle error in Err, if any.
+	ID     oerr.MsgID
+	Class  oerr.Class
+	Action Action
+	// Wait is the backoff before the next attempt.
+	Wait time.Duration
+}
+
+// Policy of retrying.
+type Policy struct {
+	// MaxAttempts is the number of attempts, including the first one.
+	// Zero means 3.
+	MaxAttempts int
+	// Backoff is the wait before the second attempt, multiplied by Multiplier
+	// for each further one, up to MaxBackoff (an hour if zero).
+	Backoff, MaxBackoff time.Duration
+	// Multiplier of the backoff, 2 if zero.
+	Multiplier float64
+	// Jitter randomizes the backoff by ± this fraction of it (0.2 is ±20%).
+	Jitter float64
+
+	// Decide the Action for the error, Decide if nil.
+	Decide func(error) Action
+	// Reconnect is called before the retries after connection errors.
+	// If nil, the function is simply called again: it should get a new connection
+	// from the pool itself (as with *sql.DB, which drops the bad ones).
+	// An error of Reconnect stops the retries.
+	Reconnect func(context.Context, error) error
+
+	// OnRetry is called before each retry, after the backoff is decided.
+	OnRetry func(Attempt)
+	// OnDone is called with the last attempt: its Err is nil on success.
+	OnDone func(Attempt)
+}
+
+// DefaultPolicy tries three times, waiting 100ms then 200ms, ±20%.
+var DefaultPolicy = Policy{MaxAttempts: 3, Backoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second, Jitter: 0.2}
+
+// Retry calls f till it succeeds, returns an error not to be retried,
+// the attempts run out or the context is done.
+//
+// The function should be a whole transaction: retryable errors (such as deadlocks
+// and snapshot too old) roll back the transaction, which has to be restarted.
+//
+// The returned error is the last error of f, or the context's error wrapping it.
+func Retry(ctx context.Context, policy Policy, f func() error) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	decide := policy.Decide
+	if decide == nil {
+		decide = Decide
+	}
+	var timer *time.Timer
+	for n := 1; ; n++ {
+		err := f()
+		a := Attempt{N: n, Err: err}
+		if err == nil {
+			if policy.OnDone != nil {
+				policy.OnDone(a)
+			}
+			return nil
+		}
+		if oe := oerr.FromError(err); oe != nil {
+			a.ID, a.Class = oe.ID, oerr.Classify(oe.ID)
+		}
+		a.Action = decide(err)
+		if ctx.Err() != nil {
+			a.Err = fmt.Errorf("%w: %w", ctx.Err(), err)
+			if policy.OnDone != nil {
+				policy.OnDone(a)
+			}
+			return a.Err
+		}
+		if a.Action == Stop || n >= maxAttempts {
+			if policy.OnDone != nil {
+				policy.OnDone(a)
+			}
+			return err
+		}
+		a.Wait = policy.backoff(n)
+		if policy.OnRetry != nil {
+			policy.OnRetry(a)
+		}
+
+		if a.Wait > 0 {
+			if timer == nil {
+				timer = time.NewTimer(a.Wait)
+				defer timer.Stop()
+			} else {
+				timer.Reset(a.Wait)
+			}
+			select {
+			case <-ctx.Done():
+				a.Err = fmt.Errorf("%w: %w", ctx.Err(), err)
+				if policy.OnDone != nil {
+					policy.OnDone(a)
+				}
+				return a.Err
+			case <-timer.C:
+			}
+		}
+		if a.Action == Reconnect && policy.Reconnect != nil {
+			if rErr := policy.Reconnect(ctx, err); rErr != nil {
+				a.Err = fmt.Errorf("reconnect: %w (after %w)", rErr, err)
+				if policy.OnDone != nil {
+					policy.OnDone(a)
+				}
+				return a.Err
+			}
+		}
+	}
+}
+
+// maxBackoff is the limit of the backoff if the Policy has no MaxBackoff,
+// so it cannot overflow.
+const maxBackoff = time.Hour
+
+// backoff returns the wait after the nth attempt.
+func (p Policy) backoff(n int) time.Duration {
+	mult := p.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+	limit := p.MaxBackoff
+	if limit <= 0 {
+		limit = maxBackoff
+	}
+	d := float64(p.Backoff)
+	for i := 1; i < n && d < float64(limit); i++ {
+		d *= mult
+	}
+	if d > float64(limit) {
+		d = float64(limit)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,155 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+// oraErr mimics godror's *OraErr.
+type oraErr struct {
+	code    int
+	message string
+}
+
+func (e *oraErr) Error() string { return e.message }
+func (e *oraErr) Code() int     { return e.code }
+
+var (
+	errDeadlock = fmt.Errorf("update: %w", &oraErr{60, "ORA-00060: deadlock detected while waiting for resource"})
+	errLost     = errors.New("query: ORA-03113: end-of-file on communication channel")
+	errUnique   = &oraErr{1, "ORA-00001: unique constraint (SCOTT.PK_EMP) violated"}
+)
+
+func TestDecide(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want Action
+	}{
+		{nil, Stop},
+		{io.EOF, Stop},
+		{context.Canceled, Stop},
+		{errDeadlock, Again},
+		{errLost, Reconnect},
+		{fmt.Errorf("exec: %w", driver.ErrBadConn), Reconnect},
+		{errUnique, Stop},
+	} {
+		if got := Decide(tc.err); got != tc.want {
+			t.Errorf("%v: got %s, wanted %s", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		errs       []error
+		wantErr    error
+		calls      int
+		reconnects int
+	}{
+		{"ok", nil, nil, 1, 0},
+		{"deadlock", []error{errDeadlock}, nil, 2, 0},
+		{"lost", []error{errLost, errLost}, nil, 3, 2},
+		{"unique", []error{errUnique}, errUnique, 1, 0},
+		{"exhausted", []error{errDeadlock, errDeadlock, errDeadlock, errDeadlock}, errDeadlock, 3, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls, reconnects int
+			var retries []Attempt
+			var done Attempt
+			policy := Policy{
+				MaxAttempts: 3,
+				Reconnect:   func(context.Context, error) error { reconnects++; return nil },
+				OnRetry:     func(a Attempt) { retries = append(retries, a) },
+				OnDone:      func(a Attempt) { done = a },
+			}
+			err := Retry(context.Background(), policy, func() error {
+				calls++
+				if calls <= len(tc.errs) {
+					return tc.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tc.wantErr) || (err == nil) != (tc.wantErr == nil) {
+				t.Errorf("got %v, wanted %v", err, tc.wantErr)
+			}
+			if calls != tc.calls || reconnects != tc.reconnects {
+				t.Errorf("got %d calls, %d reconnects, wanted %d, %d", calls, reconnects, tc.calls, tc.reconnects)
+			}
+			if len(retries) != calls-1 || done.N != calls {
+				t.Errorf("got %d retries, done at %d, after %d calls", len(retries), done.N, calls)
+			}
+			if len(retries) != 0 && retries[0].ID != oerr.FromError(tc.errs[0]).ID {
+				t.Errorf("retry: got %s", retries[0].ID)
+			}
+		})
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Retry(ctx, Policy{Backoff: time.Hour, OnRetry: func(Attempt) { cancel() }},
+		func() error { return errDeadlock })
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errDeadlock) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var done Attempt
+	err := Retry(ctx, Policy{OnDone: func(a Attempt) { done = a }},
+		func() error { cancel(); return errDeadlock })
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errDeadlock) {
+		t.Errorf("got %v", err)
+	}
+	if done.N != 1 || done.Err != err {
+		t.Errorf("done: got %d, %v", done.N, done.Err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for n, want := range []time.Duration{0, 100, 200, 400, 800, 1000, 1000} {
+		if n == 0 {
+			continue
+		}
+		if got := p.backoff(n); got != want*time.Millisecond {
+			t.Errorf("%d: got %s, wanted %s", n, got, want*time.Millisecond)
+		}
+	}
+	p.Jitter = 0.5
+	for range 100 {
+		if got := p.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("jitter: got %s", got)
+		}
+	}
+	p = Policy{Backoff: time.Second}
+	for _, n := range []int{20, 100, 10000} {
+		if got := p.backoff(n); got != maxBackoff {
+			t.Errorf("%d: got %s, wanted %s", n, got, maxBackoff)
+		}
+	}
+}
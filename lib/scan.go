@@ -0,0 +1,141 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"iter"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Occurrence is a message code found in a text.
+type Occurrence struct {
+	ID MsgID
+	// Start and End are the byte offsets of the code (ORA-06512) in the text or stream.
+	Start, End int64
+	// Line is the 1-based number of the line of the code.
+	Line int
+	// Text is the message after the code, till the next code or the end of the line,
+	// such as `at "X.Y", line 3` for `ORA-06512: at "X.Y", line 3`.
+	Text string
+}
+
+// Scanner finds the message codes, such as ORA-00001, in texts.
+type Scanner struct {
+	prefixes map[string]bool
+}
+
+// rCode matches the codes: three uppercase letters, a dash and at most five digits,
+// not within a word or a number.
+var rCode = regexp.MustCompile(`\b([A-Z]{3})-([0-9]{1,5})\b`)
+
+// NewScanner returns a Scanner finding the codes with the prefixes,
+// or with any three uppercase letters, if no prefix is given.
+func NewScanner(prefixes ...string) *Scanner {
+	s := &Scanner{}
+	if len(prefixes) != 0 {
+		s.prefixes = make(map[string]bool, len(prefixes))
+		for _, p := range prefixes {
+			s.prefixes[strings.ToUpper(p)] = true
+		}
+	}
+	return s
+}
+
+// NewDBScanner returns a Scanner finding the codes with the prefixes of the DB.
+func NewDBScanner(db Lister) (*Scanner, error) {
+	prefixes, err := Prefixes(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefixes) == 0 {
+		return nil, errors.New("no prefixes in the DB")
+	}
+	return NewScanner(prefixes...), nil
+}
+
+// FindAll returns the codes in the text.
+func (s *Scanner) FindAll(text string) []Occurrence {
+	var occs []Occurrence
+	line, lineStart := 1, 0
+	for len(text) > lineStart {
+		lineEnd := strings.IndexByte(text[lineStart:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += lineStart
+		}
+		occs = s.appendLine(occs, text[lineStart:lineEnd], int64(lineStart), line)
+		line, lineStart = line+1, lineEnd+1
+	}
+	return occs
+}
+
+// Scan the stream, line by line, for the codes.
+// The offsets are from the start of the stream.
+func (s *Scanner) Scan(r io.Reader) iter.Seq2[Occurrence, error] {
+	return func(yield func(Occurrence, error) bool) {
+		br := bufio.NewReader(r)
+		var off int64
+		for line := 1; ; line++ {
+			text, err := br.ReadString('\n')
+			for _, occ := range s.appendLine(nil, strings.TrimSuffix(text, "\n"), off, line) {
+				if !yield(occ, nil) {
+					return
+				}
+			}
+			off += int64(len(text))
+			if err != nil {
+				if err != io.EOF {
+					yield(Occurrence{}, err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// appendLine appends the codes of the line, starting at offset off, to occs.
+func (s *Scanner) appendLine(occs []Occurrence, line string, off int64, lineNo int) []Occurrence {
+	first := len(occs)
+	for _, loc := range rCode.FindAllStringSubmatchIndex(line, -1) {
+		prefix := line[loc[2]:loc[3]]
+		if s.prefixes != nil && !s.prefixes[prefix] {
+			continue
+		}
+		code, _ := strconv.ParseUint(line[loc[4]:loc[5]], 10, 32)
+		occs = append(occs, Occurrence{
+			ID:    MsgID{Prefix: prefix, Code: uint32(code)},
+			Start: off + int64(loc[0]), End: off + int64(loc[1]),
+			Line: lineNo,
+		})
+	}
+	// the texts are between the codes
+	for i := first; i < len(occs); i++ {
+		end := len(line)
+		if i+1 < len(occs) {
+			end = int(occs[i+1].Start - off)
+		}
+		text := line[occs[i].End-off : end]
+		text = strings.TrimPrefix(strings.TrimLeft(text, " \t"), ":")
+		occs[i].Text = strings.TrimSpace(text)
+	}
+	return occs
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const scanText = `2024-01-02 12:00:00 ERROR insert: ORA-00001: unique constraint (SCOTT.PK_EMP) violated
+java.sql.SQLIntegrityConstraintViolationException: ORA-01403: no data found ORA-06512: at "X.Y", line 3
+	at oracle.jdbc.driver.T4CTTIoer11.processError(T4CTTIoer11.java:498)
+see JIRA-12345 and ABC-1 and XORA-00001 and ORA-123456
+TNS-12154: TNS:could not resolve the connect identifier specified` + "\r\n"
+
+func TestScanner(t *testing.T) {
+	want := []string{
+		`1:34-43 ORA-00001 "unique constraint (SCOTT.PK_EMP) violated"`,
+		`2:51-60 ORA-01403 "no data found"`,
+		`2:76-85 ORA-06512 "at \"X.Y\", line 3"`,
+		`4:19-24 ABC-00001 "and XORA-00001 and ORA-123456"`,
+		`5:0-9 TNS-12154 "TNS:could not resolve the connect identifier specified"`,
+	}
+	lineStarts := []int{0}
+	for i, c := range scanText {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	format := func(occ Occurrence) string {
+		start := lineStarts[occ.Line-1]
+		if got := scanText[occ.Start:occ.End]; !strings.HasPrefix(got, occ.ID.Prefix+"-") {
+			t.Errorf("%v: %q", occ.ID, got)
+		}
+		return fmt.Sprintf("%d:%d-%d %s %q", occ.Line, int(occ.Start)-start, int(occ.End)-start, occ.ID, occ.Text)
+	}
+
+	var got []string
+	for _, occ := range NewScanner().FindAll(scanText) {
+		got = append(got, format(occ))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("FindAll: got\n%s\nwanted\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	mdb, err := NewMemDB(nil, seqOf(testMsgs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewDBScanner(mdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for occ, err := range s.Scan(strings.NewReader(scanText)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, format(occ))
+	}
+	// only the prefixes of the DB
+	want = append(want[:3:3], want[4])
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Scan: got\n%s\nwanted\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SourceExts are the extensions of the PL/SQL source files searched by LoadSourceTree.
+var SourceExts = []string{".pkb", ".pks", ".sql", ".pck", ".pls", ".plb", ".prc", ".fnc", ".trg", ".tpb", ".tps"}
+
+// SourceUnit is a PL/SQL unit in a source file.
+type SourceUnit struct {
+	Path string
+	// Type is PACKAGE BODY, PACKAGE, TYPE BODY, TYPE, PROCEDURE, FUNCTION or TRIGGER.
+	Type string
+	// Owner is the schema, if the CREATE statement names it.
+	Owner, Name string
+	// Line is the line of the file where the unit starts: its line 1,
+	// as the lines of the ORA-06512 frames are counted.
+	Line int
+}
+
+// FileLine returns the line of the file of the line of the unit.
+func (u SourceUnit) FileLine(line int) int { return u.Line + line - 1 }
+
+// SourceLine is a line of a source file.
+type SourceLine struct {
+	No   int
+	Text string
+}
+
+// Lines returns the line of the unit with context lines before and after it.
+func (u SourceUnit) Lines(line, context int) ([]SourceLine, error) {
+	target := u.FileLine(line)
+	fh, err := os.Open(u.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	var lines []SourceLine
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(nil, 1<<20)
+	for no := 1; scanner.Scan() && no <= target+context; no++ {
+		if no >= target-context {
+			lines = append(lines, SourceLine{No: no, Text: scanner.Text()})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// typePriority is the order of preference of the unit types for a frame:
+// the code is in the bodies, mostly.
+var typePriority = []string{"PACKAGE BODY", "TYPE BODY", "PROCEDURE", "FUNCTION", "TRIGGER", "PACKAGE", "TYPE"}
+
+// rCreate matches the CREATE statements of the PL/SQL units.
+var rCreate = regexp.MustCompile(`(?i)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:NON)?EDITIONABLE\s+)?` +
+	`(PACKAGE\s+BODY|PACKAGE|TYPE\s+BODY|TYPE|PROCEDURE|FUNCTION|TRIGGER)\s+` +
+	`(?:("[^"]+"|[\w$#]+)\s*\.\s*)?("[^"]+"|[\w$#]+)`)
+
+// SourceTree is an index of the PL/SQL units in a directory tree.
+type SourceTree struct {
+	Root  string
+	units map[string][]SourceUnit
+}
+
+// LoadSourceTree indexes the PL/SQL units of the files with SourceExts under root,
+// by their CREATE statements, or by the file names for files without one
+// (orders_pkg.pkb is the PACKAGE BODY of ORDERS_PKG).
+func LoadSourceTree(root string) (*SourceTree, error) {
+	t := &SourceTree{Root: root, units: make(map[string][]SourceUnit)}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if !slices.Contains(SourceExts, ext) {
+			return nil
+		}
+		units, err := readUnits(path)
+		if err != nil {
+			return err
+		}
+		if len(units) == 0 {
+			u := SourceUnit{Path: path, Line: 1,
+				Name: strings.ToUpper(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))}
+			switch ext {
+			case ".pkb":
+				u.Type = "PACKAGE BODY"
+			case ".pks":
+				u.Type = "PACKAGE"
+			case ".tpb":
+				u.Type = "TYPE BODY"
+			case ".tps":
+				u.Type = "TYPE"
+			default:
+				return nil
+			}
+			units = append(units, u)
+		}
+		for _, u := range units {
+			t.units[u.Name] = append(t.units[u.Name], u)
+		}
+		return nil
+	})
+	return t, err
+}
+
+// readUnits returns the units created in the file.
+func readUnits(path string) ([]SourceUnit, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	var units []SourceUnit
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(nil, 1<<20)
+	for no := 1; scanner.Scan(); no++ {
+		m := rCreate.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		units = append(units, SourceUnit{
+			Path:  path,
+			Type:  strings.Join(strings.Fields(strings.ToUpper(m[1])), " "),
+			Owner: identName(m[2]), Name: identName(m[3]),
+			Line: no,
+		})
+	}
+	return units, scanner.Err()
+}
+
+// identName returns the name of the identifier: uppercased, if not quoted.
+func identName(s string) string {
+	if len(s) > 1 && s[0] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return strings.ToUpper(s)
+}
+
+// Find the unit of the frame: the one with the same owner, or without an owner,
+// preferring the bodies.
+func (t *SourceTree) Find(f Frame) (SourceUnit, bool) {
+	if f.IsAnonymous() {
+		return SourceUnit{}, false
+	}
+	var best SourceUnit
+	bestRank := -1
+	for _, u := range t.units[f.Unit] {
+		if u.Owner != "" && f.Owner != "" && u.Owner != f.Owner {
+			continue
+		}
+		rank := 2 * (len(typePriority) - slices.Index(typePriority, u.Type))
+		if u.Owner == f.Owner {
+			rank++
+		}
+		if rank > bestRank {
+			best, bestRank = u, rank
+		}
+	}
+	return best, bestRank >= 0
+}
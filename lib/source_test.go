@@ -0,0 +1,84 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceTree(t *testing.T) {
+	dir := t.TempDir()
+	for fn, text := range map[string]string{
+		"pkg/orders_pkg.pks": "CREATE OR REPLACE PACKAGE orders_pkg AS\n  PROCEDURE run;\nEND;\n/\n",
+		"pkg/orders_pkg.pkb": "-- orders\n\nCREATE OR REPLACE EDITIONABLE PACKAGE BODY app.orders_pkg AS\n" +
+			"  PROCEDURE run IS\n  BEGIN\n    RAISE NO_DATA_FOUND;\n  END;\nEND;\n/\n",
+		"other/orders_pkg.pkb": "CREATE OR REPLACE PACKAGE BODY \"OTHER\".\"ORDERS_PKG\" AS\nEND;\n/\n",
+		"bare/util.pkb":        "PACKAGE BODY util AS\n  x NUMBER;\nEND;\n",
+		"README.md":            "CREATE OR REPLACE PACKAGE BODY readme AS\n",
+	} {
+		path := filepath.Join(dir, fn)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree, err := LoadSourceTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		frame     Frame
+		path, typ string
+		line      int
+		ok        bool
+	}{
+		{Frame{Owner: "APP", Unit: "ORDERS_PKG", Line: 4}, "pkg/orders_pkg.pkb", "PACKAGE BODY", 6, true},
+		{Frame{Owner: "OTHER", Unit: "ORDERS_PKG", Line: 1}, "other/orders_pkg.pkb", "PACKAGE BODY", 1, true},
+		{Frame{Owner: "X", Unit: "ORDERS_PKG", Line: 2}, "pkg/orders_pkg.pks", "PACKAGE", 2, true},
+		{Frame{Owner: "APP", Unit: "UTIL", Line: 2}, "bare/util.pkb", "PACKAGE BODY", 2, true},
+		{Frame{Owner: "APP", Unit: "README", Line: 1}, "", "", 0, false},
+		{Frame{Line: 1}, "", "", 0, false},
+	} {
+		u, ok := tree.Find(tc.frame)
+		if ok != tc.ok {
+			t.Errorf("%s: got %t", tc.frame, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if rel, _ := filepath.Rel(dir, u.Path); filepath.ToSlash(rel) != tc.path || u.Type != tc.typ {
+			t.Errorf("%s: got %s %s", tc.frame, u.Path, u.Type)
+		}
+		if got := u.FileLine(tc.frame.Line); got != tc.line {
+			t.Errorf("%s: got line %d, wanted %d", tc.frame, got, tc.line)
+		}
+	}
+
+	u, _ := tree.Find(Frame{Owner: "APP", Unit: "ORDERS_PKG", Line: 4})
+	lines, err := u.Lines(4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 || lines[1].No != 6 || lines[1].Text != "    RAISE NO_DATA_FOUND;" {
+		t.Errorf("Lines: got %+v", lines)
+	}
+}
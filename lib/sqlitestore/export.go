@@ -0,0 +1,188 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package sqlitestore
+
+import (
+	"database/sql"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+const exportSchema = `CREATE TABLE metadata (
+  key TEXT PRIMARY KEY,
+  value TEXT NOT NULL
+);
+CREATE TABLE releases (
+  id INTEGER PRIMARY KEY,
+  layer TEXT NOT NULL,
+  source TEXT NOT NULL,
+  created TEXT NOT NULL,
+  UNIQUE (layer, source, created)
+);
+CREATE TABLE prefixes (
+  id INTEGER PRIMARY KEY,
+  prefix TEXT NOT NULL UNIQUE,
+  count INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE messages (
+  id INTEGER PRIMARY KEY,
+  msg_id TEXT NOT NULL UNIQUE,
+  prefix_id INTEGER NOT NULL REFERENCES prefixes (id),
+  code INTEGER NOT NULL,
+  description TEXT NOT NULL,
+  cause TEXT NOT NULL,
+  action TEXT NOT NULL,
+  release_id INTEGER NOT NULL REFERENCES releases (id),
+  override INTEGER NOT NULL DEFAULT 0,
+  UNIQUE (prefix_id, code)
+);
+CREATE VIEW messages_v AS
+  SELECT M.id, M.msg_id, P.prefix, M.code, M.description, M.cause, M.action,
+         R.layer, R.source, R.created, M.override
+    FROM messages M
+    JOIN prefixes P ON P.id = M.prefix_id
+    JOIN releases R ON R.id = M.release_id;`
+
+// The external content FTS5 table over the texts of messages,
+// to be queried as
+//
+//	SELECT M.msg_id, M.description FROM messages_fts F JOIN messages M ON M.id = F.rowid
+//	 WHERE messages_fts MATCH 'deadlock' ORDER BY rank
+const ftsSchema = `CREATE VIRTUAL TABLE messages_fts USING fts5 (
+  description, cause, action,
+  content='messages', content_rowid='id'
+);
+INSERT INTO messages_fts (messages_fts) VALUES ('rebuild');`
+
+// Export the messages of the DB, with their provenance, into a new SQLite file
+// with a normalized schema (metadata, releases, prefixes, messages),
+// plus the messages_fts full text index.
+//
+// The file at path is replaced only when the export succeeds.
+func Export(path string, db oerr.DB, msgs iter.Seq2[oerr.Message, error]) error {
+	fh, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := fh.Name()
+	defer os.Remove(tmp)
+	err = fh.Chmod(0644)
+	if closeErr := fh.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = export(tmp, db, msgs); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func export(path string, db oerr.DB, msgs iter.Seq2[oerr.Message, error]) error {
+	sdb, err := sql.Open("sqlite", dsn(path, "rw", oerr.DefaultOptions))
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+	tx, err := sdb.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(exportSchema); err != nil {
+		return err
+	}
+
+	const insQry = `INSERT INTO messages (msg_id, prefix_id, code, description, cause, action, release_id, override)
+  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := tx.Prepare(insQry)
+	if err != nil {
+		return fmt.Errorf("%s: %w", insQry, err)
+	}
+	defer stmt.Close()
+	prefixes := make(map[string]int64)
+	provenance, err := oerr.Provenances(db)
+	if err != nil {
+		return err
+	}
+	releases := make(map[oerr.Provenance]int64)
+	insertID := func(qry string, args ...any) (int64, error) {
+		res, err := tx.Exec(qry, args...)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", qry, err)
+		}
+		return res.LastInsertId()
+	}
+	var n int
+	for msg, err := range msgs {
+		if err != nil {
+			return err
+		}
+		prefixID, ok := prefixes[msg.Prefix]
+		if !ok {
+			if prefixID, err = insertID("INSERT INTO prefixes (prefix) VALUES (?)", msg.Prefix); err != nil {
+				return err
+			}
+			prefixes[msg.Prefix] = prefixID
+		}
+		p := provenance(msg.MsgID)
+		override := p.Override
+		p.Override = false
+		releaseID, ok := releases[p]
+		if !ok {
+			if releaseID, err = insertID(
+				"INSERT INTO releases (layer, source, created) VALUES (?, ?, ?)",
+				p.Layer, p.Source, p.Created,
+			); err != nil {
+				return err
+			}
+			releases[p] = releaseID
+		}
+		if _, err = stmt.Exec(msg.MsgID.String(), prefixID, msg.Code,
+			msg.Description, msg.Cause, msg.Action, releaseID, override,
+		); err != nil {
+			return fmt.Errorf("%s [%s]: %w", insQry, msg.MsgID, err)
+		}
+		n++
+	}
+	if _, err = tx.Exec(
+		"UPDATE prefixes SET count = (SELECT COUNT(0) FROM messages M WHERE M.prefix_id = prefixes.id)",
+	); err != nil {
+		return err
+	}
+
+	for k, v := range db.Meta() {
+		if k == oerr.MetaCount {
+			continue
+		}
+		if _, err = tx.Exec("INSERT INTO metadata (key, value) VALUES (?, ?)", k, v); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.Exec("INSERT INTO metadata (key, value) VALUES (?, ?)", oerr.MetaCount, fmt.Sprintf("%d", n)); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ftsSchema); err != nil {
+		return fmt.Errorf("%s: %w", strings.SplitN(ftsSchema, "\n", 2)[0], err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package sqlitestore
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+func TestExport(t *testing.T) {
+	mdb, err := oerr.NewMemDB(map[string]string{oerr.MetaURL: oerr.URL}, func(yield func(oerr.Message, error) bool) {
+		for _, msg := range []oerr.Message{
+			{MsgID: oerr.MsgID{Prefix: "ORA", Code: 1}, MsgData: oerr.MsgData{Description: "unique constraint (string.string) violated"}},
+			{MsgID: oerr.MsgID{Prefix: "ORA", Code: 60}, MsgData: oerr.MsgData{Description: "deadlock detected while waiting for resource"}},
+			{MsgID: oerr.MsgID{Prefix: "PLS", Code: 201}, MsgData: oerr.MsgData{Description: "identifier 'string' must be declared"}},
+		} {
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "out.sqlite")
+	if err := Export(path, mdb, mdb.All()); err != nil {
+		t.Fatal(err)
+	}
+	// replacing
+	if err := Export(path, mdb, mdb.All()); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", dsn(path, "ro", oerr.DefaultOptions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var prefix, source, count string
+	var code int
+	if err := db.QueryRow(
+		"SELECT prefix, code, source FROM messages_v WHERE msg_id = 'PLS-00201'",
+	).Scan(&prefix, &code, &source); err != nil {
+		t.Fatal(err)
+	}
+	if prefix != "PLS" || code != 201 || source != oerr.URL {
+		t.Errorf("got %q, %d, %q", prefix, code, source)
+	}
+	if err := db.QueryRow("SELECT count FROM prefixes WHERE prefix = 'ORA'").Scan(&code); err != nil || code != 2 {
+		t.Errorf("ORA count: got %d, %v", code, err)
+	}
+	if err := db.QueryRow("SELECT value FROM metadata WHERE key = ?", oerr.MetaCount).Scan(&count); err != nil || count != "3" {
+		t.Errorf("count: got %q, %v", count, err)
+	}
+
+	var msgID string
+	if err := db.QueryRow(
+		"SELECT M.msg_id FROM messages_fts F JOIN messages M ON M.id = F.rowid WHERE messages_fts MATCH 'deadlock'",
+	).Scan(&msgID); err != nil || msgID != "ORA-00060" {
+		t.Errorf("match deadlock: got %q, %v", msgID, err)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// Package sqlitestore registers the "sqlite" storage (sqlite://path/to/oerr.sqlite).
+//
+// Import it for its side effect:
+//
+//	import _ "github.com/tgulacsi/oerr/lib/sqlitestore"
+package sqlitestore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"net/url"
+	"os"
+	"strings"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	oerr.RegisterStorage("sqlite", Storage{})
+}
+
+const schema = `CREATE TABLE IF NOT EXISTS oerr_message (
+  prefix TEXT NOT NULL,
+  code INTEGER NOT NULL,
+  description TEXT NOT NULL,
+  cause TEXT NOT NULL,
+  action TEXT NOT NULL,
+  PRIMARY KEY (prefix, code)
+) WITHOUT ROWID;
+CREATE TABLE IF NOT EXISTS oerr_meta (
+  key TEXT PRIMARY KEY,
+  value TEXT NOT NULL
+);`
+
+// Storage of catalogs in SQLite files.
+type Storage struct{}
+
+func dsn(path, mode string, opts oerr.Options) string {
+	return "file:" + (&url.URL{Path: path}).EscapedPath() +
+		fmt.Sprintf("?mode=%s&_pragma=busy_timeout(%d)", mode, opts.Timeout.Milliseconds())
+}
+
+// Open the SQLite file read-only.
+func (Storage) Open(path string, opts oerr.Options) (oerr.DB, error) {
+	// the driver would create the missing file
+	if _, err := os.Stat(path); err != nil {
+		return nil, &oerr.OpenError{Path: path, Err: err}
+	}
+	db, err := sql.Open("sqlite", dsn(path, "ro", opts))
+	if err != nil {
+		return nil, &oerr.OpenError{Path: path, Err: err}
+	}
+	var n int
+	if err = db.QueryRow("SELECT COUNT(0) FROM sqlite_master WHERE name = 'oerr_message'").Scan(&n); err == nil && n == 0 {
+		err = oerr.ErrNoBucket
+	}
+	if err != nil {
+		db.Close()
+		return nil, &oerr.OpenError{Path: path, Err: err}
+	}
+	return sqliteDB{db}, nil
+}
+
+// Replace the messages and the metadata in one transaction.
+func (Storage) Replace(path string, meta map[string]string, msgs iter.Seq2[oerr.Message, error]) error {
+	db, err := sql.Open("sqlite", dsn(path, "rwc", oerr.DefaultOptions))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err = db.Exec(schema); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, qry := range []string{"DELETE FROM oerr_message", "DELETE FROM oerr_meta"} {
+		if _, err = tx.Exec(qry); err != nil {
+			return fmt.Errorf("%s: %w", qry, err)
+		}
+	}
+	const insQry = "INSERT INTO oerr_message (prefix, code, description, cause, action) VALUES (?, ?, ?, ?, ?)"
+	stmt, err := tx.Prepare(insQry)
+	if err != nil {
+		return fmt.Errorf("%s: %w", insQry, err)
+	}
+	defer stmt.Close()
+	var n int
+	for msg, err := range msgs {
+		if err != nil {
+			return err
+		}
+		if _, err = stmt.Exec(msg.Prefix, msg.Code, msg.Description, msg.Cause, msg.Action); err != nil {
+			return fmt.Errorf("%s [%s]: %w", insQry, msg.MsgID, err)
+		}
+		n++
+	}
+	for k, v := range meta {
+		if _, err = tx.Exec("INSERT INTO oerr_meta (key, value) VALUES (?, ?)", k, v); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.Exec("INSERT INTO oerr_meta (key, value) VALUES (?, ?)", oerr.MetaCount, fmt.Sprintf("%d", n)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type sqliteDB struct {
+	*sql.DB
+}
+
+var _ oerr.DB = sqliteDB{}
+
+const selectQry = "SELECT prefix, code, description, cause, action FROM oerr_message"
+
+func (db sqliteDB) Get(id oerr.MsgID) (oerr.MsgData, error) {
+	var data oerr.MsgData
+	err := db.QueryRow(
+		"SELECT description, cause, action FROM oerr_message WHERE prefix = ? AND code = ?",
+		id.Prefix, id.Code,
+	).Scan(&data.Description, &data.Cause, &data.Action)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = oerr.ErrNotFound
+	}
+	return data, err
+}
+
+func (db sqliteDB) Meta() map[string]string {
+	m := make(map[string]string)
+	rows, err := db.Query("SELECT key, value FROM oerr_meta")
+	if err != nil {
+		return m
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k, v string
+		if rows.Scan(&k, &v) == nil {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+func (db sqliteDB) All() iter.Seq2[oerr.Message, error] {
+	return db.query(selectQry + " ORDER BY prefix, code")
+}
+
+func (db sqliteDB) Prefix(prefix string) iter.Seq2[oerr.Message, error] {
+	prefix = strings.ToUpper(prefix)
+	return db.query(selectQry+" WHERE substr(prefix, 1, length(?)) = ? ORDER BY prefix, code", prefix, prefix)
+}
+
+func (db sqliteDB) Range(from, to oerr.MsgID) iter.Seq2[oerr.Message, error] {
+	return db.query(selectQry+" WHERE (prefix, code) BETWEEN (?, ?) AND (?, ?) ORDER BY prefix, code",
+		from.Prefix, from.Code, to.Prefix, to.Code)
+}
+
+func (db sqliteDB) query(qry string, args ...any) iter.Seq2[oerr.Message, error] {
+	return func(yield func(oerr.Message, error) bool) {
+		rows, err := db.Query(qry, args...)
+		if err != nil {
+			yield(oerr.Message{}, fmt.Errorf("%s: %w", qry, err))
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var msg oerr.Message
+			if err := rows.Scan(&msg.Prefix, &msg.Code, &msg.Description, &msg.Cause, &msg.Action); err != nil {
+				yield(msg, err)
+				return
+			}
+			if !yield(msg, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(oerr.Message{}, err)
+		}
+	}
+}
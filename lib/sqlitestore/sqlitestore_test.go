@@ -0,0 +1,72 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package sqlitestore
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+func TestStorage(t *testing.T) {
+	msgs := []oerr.Message{
+		{MsgID: oerr.MsgID{Prefix: "ORA", Code: 1}, MsgData: oerr.MsgData{Description: "unique constraint (string.string) violated"}},
+		{MsgID: oerr.MsgID{Prefix: "ORA", Code: 1555}, MsgData: oerr.MsgData{Description: "snapshot too old", Cause: "undo"}},
+		{MsgID: oerr.MsgID{Prefix: "PLS", Code: 201}, MsgData: oerr.MsgData{Description: "identifier 'string' must be declared"}},
+	}
+	dbURL := "sqlite://" + filepath.Join(t.TempDir(), "oerr.sqlite")
+	if err := oerr.ReplaceURL(dbURL, map[string]string{oerr.MetaURL: oerr.URL}, func(yield func(oerr.Message, error) bool) {
+		for _, msg := range msgs {
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := oerr.OpenURL(dbURL, oerr.DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, msg := range msgs {
+		if data, err := db.Get(msg.MsgID); err != nil || data != msg.MsgData {
+			t.Errorf("Get(%s): got %v, %v", msg.MsgID, data, err)
+		}
+	}
+	if _, err := db.Get(oerr.MsgID{Prefix: "ORA", Code: 2}); !errors.Is(err, oerr.ErrNotFound) {
+		t.Errorf("Get(ORA-00002): got %v", err)
+	}
+	if meta := db.Meta(); meta[oerr.MetaURL] != oerr.URL || meta[oerr.MetaCount] != "3" {
+		t.Errorf("meta: got %v", meta)
+	}
+	var n int
+	for msg, err := range db.Range(oerr.MsgID{Prefix: "ORA", Code: 2}, oerr.MaxID("ORA")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.MsgID != msgs[1].MsgID {
+			t.Errorf("Range: got %s", msg.MsgID)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("Range: got %d messages, wanted 1", n)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FrameID is the ORA-06512 of the PL/SQL stack frames ("at "APP.PKG", line 120").
+var FrameID = MsgID{Prefix: "ORA", Code: 6512}
+
+// Frame is a PL/SQL stack frame, parsed from an ORA-06512 line.
+type Frame struct {
+	// Owner and Unit of the stored code, both empty for an anonymous block.
+	Owner, Unit string
+	// Subprogram is the third part of the name, if any ("APP.PKG.PROC").
+	Subprogram string
+	Line       int
+	// Error is the index of the error in Stack.Errors raised at the frame:
+	// the last one before the frame.
+	Error int
+}
+
+// IsAnonymous reports whether the frame is in an anonymous block.
+func (f Frame) IsAnonymous() bool { return f.Unit == "" }
+
+// Name returns the name of the unit, as OWNER.UNIT.
+func (f Frame) Name() string {
+	if f.Owner == "" {
+		return f.Unit
+	}
+	return f.Owner + "." + f.Unit
+}
+
+// String returns the frame as in the ORA-06512 message: "APP.PKG", line 120.
+func (f Frame) String() string {
+	if f.IsAnonymous() {
+		return "line " + strconv.Itoa(f.Line)
+	}
+	name := f.Name()
+	if f.Subprogram != "" {
+		name += "." + f.Subprogram
+	}
+	return fmt.Sprintf("%q, line %d", name, f.Line)
+}
+
+// rFrame matches the text of the ORA-06512 messages.
+var rFrame = regexp.MustCompile(`^at (?:"([^"]+)"|([^\s,]+)), line ([0-9]+)|^at line ([0-9]+)`)
+
+// ParseFrame parses the text of an ORA-06512 message, such as `at "APP.PKG", line 120`.
+func ParseFrame(text string) (Frame, bool) {
+	m := rFrame.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return Frame{}, false
+	}
+	if m[4] != "" {
+		line, _ := strconv.Atoi(m[4])
+		return Frame{Line: line}, true
+	}
+	var f Frame
+	f.Line, _ = strconv.Atoi(m[3])
+	name := m[1] + m[2]
+	parts := strings.SplitN(name, ".", 3)
+	switch len(parts) {
+	case 1:
+		f.Unit = parts[0]
+	case 2:
+		f.Owner, f.Unit = parts[0], parts[1]
+	default:
+		f.Owner, f.Unit, f.Subprogram = parts[0], parts[1], parts[2]
+	}
+	return f, true
+}
+
+// Stack is a parsed error stack, such as
+//
+//	ORA-20001: order not found
+//	ORA-06512: at "APP.PKG", line 120
+//	ORA-06512: at line 1
+type Stack struct {
+	// Errors are the errors of the stack, except the frames, in order:
+	// the first is the primary error, the rest are the secondary ones.
+	Errors []*Error
+	// Frames are the PL/SQL frames, in order, innermost first.
+	Frames []Frame
+}
+
+// ParseStack parses the error stack in the text. The lines without codes are ignored.
+// The Errors look up their MsgData in the db, or in the Default DB if db is nil.
+func ParseStack(text string, db DB) *Stack {
+	return stackOf(anyScanner.FindAll(text), db)
+}
+
+// ReadStack parses the error stack read from r, see ParseStack.
+func ReadStack(r io.Reader, db DB) (*Stack, error) {
+	var occs []Occurrence
+	for occ, err := range anyScanner.Scan(r) {
+		if err != nil {
+			return stackOf(occs, db), err
+		}
+		occs = append(occs, occ)
+	}
+	return stackOf(occs, db), nil
+}
+
+func stackOf(occs []Occurrence, db DB) *Stack {
+	s := &Stack{}
+	for _, occ := range occs {
+		if occ.ID == FrameID {
+			if f, ok := ParseFrame(occ.Text); ok {
+				f.Error = len(s.Errors) - 1
+				s.Frames = append(s.Frames, f)
+				continue
+			}
+		}
+		e := &Error{ID: occ.ID, Text: occ.Text}
+		if db != nil {
+			e.DB = db
+		}
+		s.Errors = append(s.Errors, e)
+	}
+	return s
+}
+
+// Primary returns the first error of the stack, or nil if there is none.
+func (s *Stack) Primary() *Error {
+	if len(s.Errors) == 0 {
+		return nil
+	}
+	return s.Errors[0]
+}
+
+// FramesOf returns the frames of the ith error.
+func (s *Stack) FramesOf(i int) []Frame {
+	var frames []Frame
+	for _, f := range s.Frames {
+		if f.Error == i {
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
+
+// String returns the stack in the Oracle format.
+func (s *Stack) String() string {
+	var buf strings.Builder
+	// the frames before the first error
+	for _, f := range s.FramesOf(-1) {
+		fmt.Fprintf(&buf, "%s: at %s\n", FrameID, f)
+	}
+	for i, e := range s.Errors {
+		buf.WriteString(e.Error())
+		buf.WriteByte('\n')
+		for _, f := range s.FramesOf(i) {
+			fmt.Fprintf(&buf, "%s: at %s\n", FrameID, f)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,92 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFrame(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Frame
+		ok   bool
+	}{
+		{`at "APP.PKG", line 120`, Frame{Owner: "APP", Unit: "PKG", Line: 120}, true},
+		{`at "APP.PKG.PROC", line 3`, Frame{Owner: "APP", Unit: "PKG", Subprogram: "PROC", Line: 3}, true},
+		{`at "PKG", line 7`, Frame{Unit: "PKG", Line: 7}, true},
+		{`at line 1`, Frame{Line: 1}, true},
+		{`somewhere`, Frame{}, false},
+	} {
+		got, ok := ParseFrame(tc.in)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("%q: got %+v, %t, wanted %+v", tc.in, got, ok, tc.want)
+		}
+		if ok && got.String() != strings.TrimPrefix(tc.in, "at ") {
+			t.Errorf("%q: String is %q", tc.in, got.String())
+		}
+	}
+}
+
+func TestParseStack(t *testing.T) {
+	mdb, err := NewMemDB(nil, seqOf(testMsgs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const text = `BEGIN app.pkg.run; END;
+ERROR at line 1:
+ORA-00060: deadlock detected while waiting for resource
+ORA-06512: at "APP.PKG", line 10
+ORA-06512: at "APP.PKG", line 120
+ORA-20001: order 12 failed
+ORA-06512: at line 1
+`
+	st := ParseStack(text, mdb)
+	var ids []MsgID
+	for _, e := range st.Errors {
+		ids = append(ids, e.ID)
+	}
+	if want := []MsgID{{"ORA", 60}, {"ORA", 20001}}; !slices.Equal(ids, want) {
+		t.Fatalf("got %v, wanted %v", ids, want)
+	}
+	if p := st.Primary(); p.Text != "deadlock detected while waiting for resource" {
+		t.Errorf("primary: got %q", p.Text)
+	}
+	if data, err := st.Primary().Data(); err != nil || data.Description != "deadlock detected while waiting for resource" {
+		t.Errorf("primary data: got %v, %v", data, err)
+	}
+	want := []Frame{
+		{Owner: "APP", Unit: "PKG", Line: 10},
+		{Owner: "APP", Unit: "PKG", Line: 120},
+		{Line: 1, Error: 1},
+	}
+	if !slices.Equal(st.Frames, want) {
+		t.Errorf("got %+v, wanted %+v", st.Frames, want)
+	}
+	if got := len(st.FramesOf(0)); got != 2 {
+		t.Errorf("FramesOf(0): got %d", got)
+	}
+
+	st2, err := ReadStack(strings.NewReader(text), mdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := st2.String(), text[strings.Index(text, "ORA-"):]; got != want {
+		t.Errorf("String: got\n%s\nwanted\n%s", got, want)
+	}
+}
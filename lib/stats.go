@@ -0,0 +1,116 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const metaBucketName = "meta"
+
+// Meta keys written by DownloadInto.
+const (
+	MetaURL     = "url"
+	MetaCreated = "created"
+	MetaCount   = "count"
+)
+
+// Meta keys of the last import merged into the catalog (see MergeURL).
+const (
+	MetaImported   = "imported"
+	MetaImportedAt = "imported_at"
+)
+
+// Meta returns the build metadata stored in the DB.
+func (db *dbS) Meta() map[string]string {
+	m := make(map[string]string)
+	db.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(metaBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			m[string(k)] = string(v)
+			return nil
+		})
+	})
+	return m
+}
+
+// Stats of the catalog.
+type Stats struct {
+	Path     string            `json:"path,omitempty"`
+	Size     int64             `json:"size,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Total    int               `json:"total"`
+	Prefixes map[string]int    `json:"prefixes"`
+	// Chapters counts the messages per thousand codes (ORA-01xxx).
+	Chapters map[string]int `json:"chapters"`
+	NoCause  []string       `json:"no_cause,omitempty"`
+	NoAction []string       `json:"no_action,omitempty"`
+	Longest  []TextLen      `json:"longest,omitempty"`
+}
+
+// TextLen is the length of a message's texts (description, cause and action).
+type TextLen struct {
+	ID     string `json:"id"`
+	Length int    `json:"length"`
+}
+
+// Chapter returns the thousand-codes chapter of the id, as ORA-01xxx.
+func Chapter(id MsgID) string {
+	return fmt.Sprintf("%s-%02dxxx", id.Prefix, id.Code/1000)
+}
+
+// GetStats walks the whole catalog, and collects the top longest texts.
+func GetStats(db DB, top int) (Stats, error) {
+	st := Stats{
+		Meta:     db.Meta(),
+		Prefixes: make(map[string]int),
+		Chapters: make(map[string]int),
+	}
+	for msg, err := range db.All() {
+		if err != nil {
+			return st, err
+		}
+		st.Total++
+		st.Prefixes[msg.Prefix]++
+		st.Chapters[Chapter(msg.MsgID)]++
+		if msg.Cause == "" {
+			st.NoCause = append(st.NoCause, msg.MsgID.String())
+		}
+		if msg.Action == "" {
+			st.NoAction = append(st.NoAction, msg.MsgID.String())
+		}
+		if top <= 0 {
+			continue
+		}
+		tl := TextLen{ID: msg.MsgID.String(), Length: len(msg.Description) + len(msg.Cause) + len(msg.Action)}
+		if len(st.Longest) == top && st.Longest[top-1].Length >= tl.Length {
+			continue
+		}
+		i := sort.Search(len(st.Longest), func(i int) bool { return st.Longest[i].Length < tl.Length })
+		if len(st.Longest) < top {
+			st.Longest = append(st.Longest, TextLen{})
+		}
+		copy(st.Longest[i+1:], st.Longest[i:])
+		st.Longest[i] = tl
+	}
+	return st, nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	db, err := Open(newTestDB(t, testMsgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	st, err := GetStats(db, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Total != len(testMsgs) {
+		t.Errorf("total: got %d, wanted %d", st.Total, len(testMsgs))
+	}
+	if st.Prefixes["ORA"] != 5 || st.Chapters["ORA-01xxx"] != 3 {
+		t.Errorf("got prefixes=%v chapters=%v", st.Prefixes, st.Chapters)
+	}
+	if len(st.NoCause) != len(testMsgs) {
+		t.Errorf("no cause: got %v", st.NoCause)
+	}
+	if len(st.Longest) != 2 || st.Longest[0].ID != "TNS-12154" || st.Longest[1].ID != "ORA-01600" {
+		t.Errorf("longest: got %v", st.Longest)
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Storage reads and writes catalogs.
+//
+// Storages are selected by the scheme of the DB URL (scheme://path),
+// see RegisterStorage. Plain paths are Bolt DBs or packed files.
+type Storage interface {
+	// Open the catalog at path for reading.
+	Open(path string, opts Options) (DB, error)
+	// Replace the (downloaded) messages and the metadata of the catalog at path,
+	// creating it if needed. MetaCount is set to the number of messages.
+	Replace(path string, meta map[string]string, msgs iter.Seq2[Message, error]) error
+}
+
+var storages = struct {
+	sync.RWMutex
+	m map[string]Storage
+}{m: map[string]Storage{
+	"bolt": boltStorage{},
+	"pack": packStorage{},
+	"mem":  &memStorage{dbs: make(map[string]*MemDB)},
+}}
+
+// RegisterStorage makes the storage available by the scheme.
+// It panics if the scheme is already registered.
+func RegisterStorage(scheme string, s Storage) {
+	storages.Lock()
+	defer storages.Unlock()
+	if _, ok := storages.m[scheme]; ok {
+		panic("oerr: RegisterStorage called twice for " + scheme)
+	}
+	storages.m[scheme] = s
+}
+
+// Storages returns the registered schemes.
+func Storages() []string {
+	storages.RLock()
+	defer storages.RUnlock()
+	schemes := make([]string, 0, len(storages.m))
+	for k := range storages.m {
+		schemes = append(schemes, k)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// ErrUnknownStorage is returned for a DB URL with an unregistered scheme.
+var ErrUnknownStorage = errors.New("unknown storage")
+
+// SplitURL splits the DB URL into scheme and path; the scheme of a plain path is empty.
+func SplitURL(dbURL string) (scheme, path string) {
+	if i := strings.Index(dbURL, "://"); i > 0 && isScheme(dbURL[:i]) {
+		return dbURL[:i], dbURL[i+3:]
+	}
+	return "", dbURL
+}
+
+func isScheme(s string) bool {
+	for _, c := range s {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// SplitSearchPath splits the search path like filepath.SplitList,
+// but keeps the scheme://path URLs together.
+func SplitSearchPath(searchPath string) []string {
+	parts := filepath.SplitList(searchPath)
+	urls := parts[:0]
+	for i := 0; i < len(parts); i++ {
+		if i+1 < len(parts) && filepath.ListSeparator == ':' &&
+			isScheme(parts[i]) && strings.HasPrefix(parts[i+1], "//") {
+			parts[i+1] = parts[i] + ":" + parts[i+1]
+			continue
+		}
+		urls = append(urls, parts[i])
+	}
+	return urls
+}
+
+func storage(dbURL string) (Storage, string, error) {
+	scheme, path := SplitURL(dbURL)
+	if scheme == "" {
+		scheme = "bolt"
+		if isPackFile(path) {
+			scheme = "pack"
+		}
+	}
+	storages.RLock()
+	s, ok := storages.m[scheme]
+	storages.RUnlock()
+	if !ok {
+		return nil, path, fmt.Errorf("%w %q", ErrUnknownStorage, scheme)
+	}
+	return s, path, nil
+}
+
+// OpenURL opens the catalog at the DB URL.
+// Plain paths are opened as packed files (see WritePack) or Bolt DBs.
+func OpenURL(dbURL string, opts Options) (DB, error) {
+	s, path, err := storage(dbURL)
+	if err != nil {
+		return nil, &OpenError{Path: dbURL, Err: err}
+	}
+	return s.Open(path, opts)
+}
+
+// ReplaceURL replaces the messages of the catalog at the DB URL, see Storage.Replace.
+func ReplaceURL(dbURL string, meta map[string]string, msgs iter.Seq2[Message, error]) error {
+	s, path, err := storage(dbURL)
+	if err != nil {
+		return err
+	}
+	return s.Replace(path, meta, msgs)
+}
+
+// Merger is implemented by the Storages which can add and overwrite
+// messages without rewriting the whole catalog.
+type Merger interface {
+	// Merge the messages and the metadata into the catalog at path, creating it if needed.
+	Merge(path string, meta map[string]string, msgs iter.Seq2[Message, error]) error
+}
+
+// MergeURL merges the messages into the catalog at the DB URL, the new ones winning.
+// For Storages not implementing Merger, the catalog is read and replaced.
+//
+// The provenance of the catalog (MetaURL, MetaCreated) is kept,
+// the merged messages should be recorded under MetaImported and MetaImportedAt.
+func MergeURL(dbURL string, meta map[string]string, msgs iter.Seq2[Message, error]) error {
+	s, path, err := storage(dbURL)
+	if err != nil {
+		return err
+	}
+	if m, ok := s.(Merger); ok {
+		return m.Merge(path, meta, msgs)
+	}
+	all := make(map[string]string)
+	old, err := s.Open(path, DefaultOptions)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var mdb *MemDB
+	if old == nil {
+		mdb, err = NewMemDB(nil, msgs)
+	} else {
+		for k, v := range old.Meta() {
+			all[k] = v
+		}
+		mdb, err = NewMemDB(nil, func(yield func(Message, error) bool) {
+			for _, seq := range []iter.Seq2[Message, error]{old.All(), msgs} {
+				for msg, err := range seq {
+					if !yield(msg, err) {
+						return
+					}
+				}
+			}
+		})
+		old.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return s.Replace(path, mergeMeta(all, meta), mdb.All())
+}
+
+// mergeMeta sets the meta into old, but keeps its provenance (MetaURL, MetaCreated).
+func mergeMeta(old, meta map[string]string) map[string]string {
+	for k, v := range meta {
+		if (k == MetaURL || k == MetaCreated) && old[k] != "" {
+			continue
+		}
+		old[k] = v
+	}
+	return old
+}
+
+// isPackFile reports whether the file starts with the magic of WritePack.
+func isPackFile(path string) bool {
+	fh, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer fh.Close()
+	magic := make([]byte, len(packMagic))
+	_, err = io.ReadFull(fh, magic)
+	return err == nil && string(magic) == packMagic
+}
+
+func withCount(meta map[string]string, n int) map[string]string {
+	m := make(map[string]string, len(meta)+1)
+	for k, v := range meta {
+		m[k] = v
+	}
+	m[MetaCount] = strconv.Itoa(n)
+	return m
+}
+
+// boltStorage keeps the overrides and notes on Replace.
+type boltStorage struct{}
+
+// openBolt opens the Bolt DB for writing, waiting for the readers' lock
+// at most DefaultOptions.Timeout.
+func openBolt(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0664, &bolt.Options{Timeout: DefaultOptions.Timeout})
+	if err != nil {
+		if err == bolt.ErrTimeout {
+			err = ErrTimeout
+		}
+		return nil, &OpenError{Path: path, Err: err}
+	}
+	return db, nil
+}
+
+func (boltStorage) Open(path string, opts Options) (DB, error) { return OpenOptions(path, opts) }
+
+func (boltStorage) Replace(path string, meta map[string]string, msgs iter.Seq2[Message, error]) error {
+	db, err := openBolt(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	db.NoSync = true
+	defer db.Sync()
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, nm := range []string{bucketName, metaBucketName} {
+			if err := tx.DeleteBucket([]byte(nm)); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		bucket, err := tx.CreateBucket([]byte(bucketName))
+		if err != nil {
+			return err
+		}
+		var n int
+		for msg, err := range msgs {
+			if err != nil {
+				return err
+			}
+			if err = put(bucket, msg); err != nil {
+				return fmt.Errorf("put %s: %w", msg.MsgID, err)
+			}
+			n++
+		}
+
+		metaBucket, err := tx.CreateBucket([]byte(metaBucketName))
+		if err != nil {
+			return err
+		}
+		for k, v := range withCount(meta, n) {
+			if err := metaBucket.Put([]byte(k), []byte(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (boltStorage) Merge(path string, meta map[string]string, msgs iter.Seq2[Message, error]) error {
+	db, err := openBolt(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(bucketName))
+		if err != nil {
+			return err
+		}
+		for msg, err := range msgs {
+			if err != nil {
+				return err
+			}
+			if err = put(bucket, msg); err != nil {
+				return fmt.Errorf("put %s: %w", msg.MsgID, err)
+			}
+		}
+		metaBucket, err := tx.CreateBucketIfNotExists([]byte(metaBucketName))
+		if err != nil {
+			return err
+		}
+		old := make(map[string]string)
+		metaBucket.ForEach(func(k, v []byte) error { old[string(k)] = string(v); return nil })
+		var n int
+		bucket.ForEach(func(_, _ []byte) error { n++; return nil })
+		for k, v := range withCount(mergeMeta(old, meta), n) {
+			if err := metaBucket.Put([]byte(k), []byte(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// packStorage replaces the file atomically.
+type packStorage struct{}
+
+func (packStorage) Open(path string, _ Options) (DB, error) { return OpenPack(path) }
+
+func (packStorage) Replace(path string, meta map[string]string, msgs iter.Seq2[Message, error]) error {
+	mdb, err := NewMemDB(meta, msgs)
+	if err != nil {
+		return err
+	}
+	fh, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(fh.Name())
+	if err = fh.Chmod(0644); err != nil {
+		fh.Close()
+		return err
+	}
+	if err = WritePack(fh, withCount(meta, len(mdb.msgs)), mdb.All()); err != nil {
+		fh.Close()
+		return err
+	}
+	if err = fh.Close(); err != nil {
+		return err
+	}
+	return os.Rename(fh.Name(), path)
+}
+
+// memStorage keeps the catalogs in memory, by name, for the life of the process.
+type memStorage struct {
+	mu  sync.Mutex
+	dbs map[string]*MemDB
+}
+
+func (s *memStorage) Open(path string, _ Options) (DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if db, ok := s.dbs[path]; ok {
+		return db, nil
+	}
+	return nil, &OpenError{Path: "mem://" + path, Err: os.ErrNotExist}
+}
+
+func (s *memStorage) Replace(path string, meta map[string]string, msgs iter.Seq2[Message, error]) error {
+	db, err := NewMemDB(meta, msgs)
+	if err != nil {
+		return err
+	}
+	db.meta = withCount(meta, len(db.msgs))
+	s.mu.Lock()
+	s.dbs[path] = db
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"errors"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSplitSearchPath(t *testing.T) {
+	if filepath.ListSeparator != ':' {
+		t.Skip("not a colon separated search path")
+	}
+	got := SplitSearchPath("team.db:sqlite:///var/lib/oerr.sqlite:mem://test:/usr/share/oerr.db")
+	want := []string{"team.db", "sqlite:///var/lib/oerr.sqlite", "mem://test", "/usr/share/oerr.db"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestStorages(t *testing.T) {
+	dir := t.TempDir()
+	for _, dbURL := range []string{
+		filepath.Join(dir, "oerr.db"),
+		"bolt://" + filepath.Join(dir, "oerr2.db"),
+		"pack://" + filepath.Join(dir, "oerr.pack"),
+		"mem://test",
+	} {
+		if _, err := OpenURL(dbURL, DefaultOptions); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: open before replace: got %v, wanted ErrNotExist", dbURL, err)
+		}
+		if err := ReplaceURL(dbURL, map[string]string{MetaURL: URL}, seqOf(testMsgs...)); err != nil {
+			t.Fatalf("%s: %v", dbURL, err)
+		}
+		db, err := OpenURL(dbURL, DefaultOptions)
+		if err != nil {
+			t.Fatalf("%s: %v", dbURL, err)
+		}
+		if data, err := db.Get(testMsgs[1].MsgID); err != nil || data != testMsgs[1].MsgData {
+			t.Errorf("%s: Get(%s): got %v, %v", dbURL, testMsgs[1].MsgID, data, err)
+		}
+		if meta := db.Meta(); meta[MetaURL] != URL || meta[MetaCount] != "7" {
+			t.Errorf("%s: meta: got %v", dbURL, meta)
+		}
+		db.Close()
+	}
+	if _, err := OpenURL("nosuch://x", DefaultOptions); !errors.Is(err, ErrUnknownStorage) {
+		t.Errorf("got %v, wanted ErrUnknownStorage", err)
+	}
+}
+
+func TestMergeURL(t *testing.T) {
+	dir := t.TempDir()
+	custom := Message{MsgID: MsgID{Prefix: "ORA", Code: 20001}, MsgData: MsgData{Description: "custom"}}
+	patched := Message{MsgID: testMsgs[0].MsgID, MsgData: MsgData{Description: "patched"}}
+	for _, dbURL := range []string{
+		filepath.Join(dir, "oerr.db"),
+		"pack://" + filepath.Join(dir, "oerr.pack"),
+	} {
+		if err := ReplaceURL(dbURL, map[string]string{MetaURL: URL, MetaCreated: "yesterday"}, seqOf(testMsgs[1:]...)); err != nil {
+			t.Fatalf("%s: %v", dbURL, err)
+		}
+		meta := map[string]string{MetaURL: "a.csv", MetaCreated: "now", MetaImported: "a.csv", MetaImportedAt: "now"}
+		if err := MergeURL(dbURL, meta, seqOf(custom, patched)); err != nil {
+			t.Fatalf("%s: %v", dbURL, err)
+		}
+		db, err := OpenURL(dbURL, DefaultOptions)
+		if err != nil {
+			t.Fatalf("%s: %v", dbURL, err)
+		}
+		for _, msg := range []Message{custom, patched, testMsgs[1]} {
+			if data, err := db.Get(msg.MsgID); err != nil || data != msg.MsgData {
+				t.Errorf("%s: Get(%s): got %v, %v", dbURL, msg.MsgID, data, err)
+			}
+		}
+		// the provenance of the catalog is kept
+		if meta := db.Meta(); meta[MetaURL] != URL || meta[MetaCreated] != "yesterday" ||
+			meta[MetaImported] != "a.csv" || meta[MetaImportedAt] != "now" ||
+			meta[MetaCount] != strconv.Itoa(len(testMsgs)+1) {
+			t.Errorf("%s: meta: got %v", dbURL, meta)
+		}
+		if p := GetProvenance(db, testMsgs[1].MsgID); p.Source != URL || p.Created != "yesterday" {
+			t.Errorf("%s: provenance: got %+v", dbURL, p)
+		}
+		db.Close()
+	}
+
+	// Merging into the catalog keeps the overrides.
+	dbPath := filepath.Join(dir, "oerr.db")
+	w, err := OpenWriter(dbPath, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	override := Message{MsgID: custom.MsgID, MsgData: MsgData{Description: "override"}}
+	if err := w.Put(override); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if err := MergeURL(dbPath, nil, seqOf(custom)); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if data, err := db.Get(custom.MsgID); err != nil || data != override.MsgData {
+		t.Errorf("Get(%s): got %v, %v", custom.MsgID, data, err)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	dbPath := newTestDB(t, testMsgs)
+	r, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	defer func(timeout time.Duration) { DefaultOptions.Timeout = timeout }(DefaultOptions.Timeout)
+	DefaultOptions.Timeout = 100 * time.Millisecond
+	for nm, f := range map[string]func(string, map[string]string, iter.Seq2[Message, error]) error{
+		"replace": ReplaceURL, "merge": MergeURL,
+	} {
+		err := f(dbPath, nil, seqOf(testMsgs...))
+		var oe *OpenError
+		if !errors.As(err, &oe) || !errors.Is(err, ErrTimeout) {
+			t.Errorf("%s: got %#v, wanted OpenError with ErrTimeout", nm, err)
+		}
+	}
+}
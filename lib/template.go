@@ -0,0 +1,213 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ArgKind is the kind of a placeholder.
+type ArgKind uint8
+
+const (
+	// ArgString is the "string" placeholder.
+	ArgString = ArgKind(iota)
+	// ArgNumber is the "number" placeholder.
+	ArgNumber
+)
+
+func (k ArgKind) String() string {
+	if k == ArgNumber {
+		return "number"
+	}
+	return "string"
+}
+
+// Arg is a placeholder of the Template.
+type Arg struct {
+	Kind ArgKind
+	// Name is the last word before the placeholder, such as "column" and "actual"
+	// in "value too large for column string (actual: number, maximum: number)",
+	// or the previous placeholder's name, suffixed by the position, if there is no word
+	// (the second string of "(string.string)" is "constraint2").
+	Name string
+}
+
+// Template is a parsed description, the placeholders ("string" and "number") separated.
+type Template struct {
+	// Texts are the literal parts around the Args: len(Texts) == len(Args)+1.
+	Texts []string
+	Args  []Arg
+	rx    *regexp.Regexp
+}
+
+// ParseTemplate parses the description.
+//
+// The words "string" and "number" are always placeholders, even when they are meant literally,
+// as in "maximum number of processes (string) exceeded".
+func ParseTemplate(desc string) *Template {
+	t := &Template{}
+	var text strings.Builder
+	isWordRune := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for i := 0; i < len(desc); {
+		var kind ArgKind
+		var word string
+		switch {
+		case strings.HasPrefix(desc[i:], "string"):
+			kind, word = ArgString, "string"
+		case strings.HasPrefix(desc[i:], "number"):
+			kind, word = ArgNumber, "number"
+		}
+		if word != "" {
+			before, _ := utf8.DecodeLastRuneInString(desc[:i])
+			after, _ := utf8.DecodeRuneInString(desc[i+len(word):])
+			if isWordRune(before) || isWordRune(after) {
+				word = ""
+			}
+		}
+		if word != "" {
+			t.Texts = append(t.Texts, text.String())
+			text.Reset()
+			t.Args = append(t.Args, Arg{Kind: kind, Name: t.argName(t.Texts[len(t.Texts)-1])})
+			i += len(word)
+			continue
+		}
+		text.WriteByte(desc[i])
+		i++
+	}
+	t.Texts = append(t.Texts, text.String())
+	t.rx = t.compile()
+	return t
+}
+
+// argName returns the name of the next placeholder, after the text.
+func (t *Template) argName(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) })
+	if len(words) != 0 {
+		return strings.ToLower(words[len(words)-1])
+	}
+	if len(t.Args) == 0 {
+		return "arg1"
+	}
+	prev := strings.TrimRight(t.Args[len(t.Args)-1].Name, "0123456789")
+	return prev + strconv.Itoa(len(t.Args)+1)
+}
+
+var rWhitespace = regexp.MustCompile(`\s+`)
+
+// compile the regexp matching the runtime texts of the template.
+func (t *Template) compile() *regexp.Regexp {
+	var buf strings.Builder
+	buf.WriteString(`(?s)^`)
+	for i, text := range t.Texts {
+		if i != 0 {
+			if t.Args[i-1].Kind == ArgNumber {
+				// the description's "number" may be a word, not a placeholder
+				buf.WriteString(`([-+]?[0-9]+(?:[.,][0-9]+)?|number)`)
+			} else {
+				buf.WriteString(`(.+?)`)
+			}
+		}
+		parts := rWhitespace.Split(text, -1)
+		for j, part := range parts {
+			if j != 0 {
+				buf.WriteString(`\s+`)
+			}
+			buf.WriteString(regexp.QuoteMeta(part))
+		}
+	}
+	// The runtime messages may have trailing details (such as the table and the columns
+	// of ORA-00001 since 23ai), which can be ignored after a literal ending,
+	// but the last placeholder has to span till the end.
+	if last := t.Texts[len(t.Texts)-1]; len(t.Args) != 0 && strings.TrimSpace(last) == "" {
+		buf.WriteString(`\s*$`)
+	}
+	return regexp.MustCompile(buf.String())
+}
+
+// String returns the description.
+func (t *Template) String() string {
+	var buf strings.Builder
+	for i, text := range t.Texts {
+		if i != 0 {
+			buf.WriteString(t.Args[i-1].Kind.String())
+		}
+		buf.WriteString(text)
+	}
+	return buf.String()
+}
+
+// Format the template with the args, like fmt.Sprint. The missing args are left as placeholders,
+// the extra args are ignored.
+func (t *Template) Format(args ...any) string {
+	var buf strings.Builder
+	for i, text := range t.Texts {
+		if i != 0 {
+			if i-1 < len(args) {
+				fmt.Fprint(&buf, args[i-1])
+			} else {
+				buf.WriteString(t.Args[i-1].Kind.String())
+			}
+		}
+		buf.WriteString(text)
+	}
+	return buf.String()
+}
+
+// rMsgIDPrefix is the "ORA-12899: " prefix of the runtime messages.
+var rMsgIDPrefix = regexp.MustCompile(`^\s*[A-Z]{3}-[0-9]{5}:\s*`)
+
+// Match the runtime text (with or without the "ORA-12899: " prefix) against the template,
+// returning the values of the placeholders, in the order of Args:
+//
+//	t := ParseTemplate("value too large for column string (actual: number, maximum: number)")
+//	t.Match(`ORA-12899: value too large for column "SCOTT"."EMP"."ENAME" (actual: 20, maximum: 10)`)
+//	// [`"SCOTT"."EMP"."ENAME"` "20" "10"] true
+func (t *Template) Match(actual string) ([]string, bool) {
+	actual = rMsgIDPrefix.ReplaceAllString(actual, "")
+	m := t.rx.FindStringSubmatch(actual)
+	if m == nil {
+		return nil, false
+	}
+	return m[1:], true
+}
+
+// MatchNamed is Match, returning the values by the Args' names.
+func (t *Template) MatchNamed(actual string) (map[string]string, bool) {
+	values, ok := t.Match(actual)
+	if !ok {
+		return nil, false
+	}
+	m := make(map[string]string, len(values))
+	for i, v := range values {
+		m[t.Args[i].Name] = v
+	}
+	return m, true
+}
+
+// Template returns the parsed description.
+func (d MsgData) Template() *Template { return ParseTemplate(d.Description) }
+
+// Format the description with the args, see Template.Format.
+func (d MsgData) Format(args ...any) string { return d.Template().Format(args...) }
+
+// Match the runtime text against the description, see Template.Match.
+func (d MsgData) Match(actual string) ([]string, bool) { return d.Template().Match(actual) }
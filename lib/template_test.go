@@ -0,0 +1,93 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package oerr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		desc, actual string
+		names        string
+		values       string
+		format       string
+	}{
+		{
+			desc:   "value too large for column string (actual: number, maximum: number)",
+			actual: `ORA-12899: value too large for column "SCOTT"."EMP"."ENAME" (actual: 20, maximum: 10)`,
+			names:  "[column actual maximum]", values: `["\"SCOTT\".\"EMP\".\"ENAME\"" "20" "10"]`,
+			format: "value too large for column X (actual: 20, maximum: number)",
+		},
+		{
+			desc:   "unique constraint (string.string) violated",
+			actual: "ORA-00001: unique constraint (SCOTT.PK_EMP) violated on table SCOTT.EMP columns (EMPNO)",
+			names:  "[constraint constraint2]", values: `["SCOTT" "PK_EMP"]`,
+			format: "unique constraint (X.20) violated",
+		},
+		{
+			desc:   "at string line string",
+			actual: `at "SCOTT.PKG", line 12`,
+			names:  "[at line]", values: `["\"SCOTT.PKG\"," "12"]`,
+			format: "at X line 20",
+		},
+		{
+			desc:   "stringent substring check on number_of_rows",
+			actual: "stringent substring check on number_of_rows",
+			names:  "[]", values: "[]",
+			format: "stringent substring check on number_of_rows",
+		},
+		{
+			desc:   "maximum number of processes (string) exceeded",
+			actual: "ORA-00020: maximum number of processes (300) exceeded",
+			names:  "[maximum processes]", values: `["number" "300"]`,
+			format: "maximum X of processes (20) exceeded",
+		},
+	} {
+		tmpl := MsgData{Description: tc.desc}.Template()
+		if got := tmpl.String(); got != tc.desc {
+			t.Errorf("%q: String: got %q", tc.desc, got)
+		}
+		var names []string
+		for _, a := range tmpl.Args {
+			names = append(names, a.Name)
+		}
+		if got := fmt.Sprintf("%v", names); got != tc.names {
+			t.Errorf("%q: names: got %s, wanted %s", tc.desc, got, tc.names)
+		}
+		values, ok := tmpl.Match(tc.actual)
+		got := fmt.Sprintf("%q", values)
+		if !ok {
+			got = "no match"
+		}
+		if got != tc.values {
+			t.Errorf("%q: Match: got %s, wanted %s", tc.desc, got, tc.values)
+		}
+		if got := tmpl.Format("X", 20); got != tc.format {
+			t.Errorf("%q: Format: got %q, wanted %q", tc.desc, got, tc.format)
+		}
+	}
+
+	m, ok := ParseTemplate("value too large for column string (actual: number, maximum: number)").
+		MatchNamed("value too large for column C (actual: 3, maximum: 2)")
+	if !ok || m["column"] != "C" || m["actual"] != "3" || m["maximum"] != "2" {
+		t.Errorf("MatchNamed: got %v, %t", m, ok)
+	}
+	if _, ok := (MsgData{Description: "deadlock detected while waiting for resource"}).Match("ORA-00060: something else"); ok {
+		t.Error("matched a different text")
+	}
+}
@@ -18,29 +18,44 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"log"
+	"maps"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	oerr "github.com/tgulacsi/oerr/lib"
+	"github.com/tgulacsi/oerr/lib/gen"
+	"github.com/tgulacsi/oerr/lib/sqlitestore"
 )
 
 //go:generate sh -c "go install && oerr download oerr.db"
 
 func main() {
-	dbPath := "oerr.db"
-	if os.Getenv("BRUNO_HOME") != "" {
-		dbPath = os.ExpandEnv("$BRUNO_HOME/data/ws/oerr.db")
-	}
+	dbPath := oerr.DefaultPath()
 	URL := oerr.URL
 
 	mainCmd := &cobra.Command{
 		Use: "oerr",
 	}
-	mainCmd.PersistentFlags().StringVarP(&dbPath, "db", "D", dbPath, "path of the Bolt DB of Oracle Error Messages")
+	mainCmd.PersistentFlags().StringVarP(&dbPath, "db", "D", dbPath, "path or URL (bolt://, pack://, sqlite://) of the DB of Oracle Error Messages, or a search path of them (the first is written)")
+	mainCmd.PersistentFlags().DurationVarP(&oerr.DefaultOptions.Timeout, "timeout", "", oerr.DefaultOptions.Timeout, "time to wait for the DB lock")
 
 	downloadCmd := &cobra.Command{
 		Use: "download",
@@ -53,37 +68,1244 @@ func main() {
 	downloadCmd.Flags().StringVarP(&URL, "url", "", URL, "URL of TOC")
 	mainCmd.AddCommand(downloadCmd)
 
+	var getMap bool
+	mappingsFile := os.Getenv("OERR_MAPPINGS")
 	getCmd := &cobra.Command{
-		Use: "get",
+		Use:   "get ID",
+		Short: "get the message (ORA-00001, ora-1, -1, 1403, TNS-12154)",
+		Args:  cobra.ExactArgs(1),
 		Run: func(_ *cobra.Command, args []string) {
-			id := oerr.MsgID{Prefix: "ORA"}
-			txt := strings.TrimSpace(args[0])
-			i := strings.IndexByte(txt, '-')
-			if i >= 3 {
-				id.Prefix = strings.ToUpper(txt[:3])
-			}
-			code, err := strconv.Atoi(txt[i+1:])
+			db, err := openDB(dbPath)
 			if err != nil {
-				log.Fatalf("Parse %q as integer: %v", txt[i+1:], err)
+				log.Fatalf("Open %q: %v", dbPath, err)
 			}
-			id.Code = uint32(code)
-
-			db, err := oerr.Open(dbPath)
+			defer db.Close()
+			if getMap && mappingsFile != "" {
+				if err := oerr.LoadMappingsFile(mappingsFile); err != nil {
+					log.Fatal(err)
+				}
+			}
+			id, err := oerr.ResolveMsgID(db, args[0])
 			if err != nil {
-				log.Fatalf("Open %q: %v", dbPath, err)
+				log.Fatal(err)
+			}
+			var data oerr.MsgData
+			var layer string
+			if l, ok := db.(*oerr.Layered); ok {
+				data, layer, err = l.GetLayer(id)
+			} else {
+				data, err = db.Get(id)
 			}
-			data, err := db.Get(id)
 			if err != nil {
 				log.Printf("get %s: %v", id, err)
 			} else {
 				fmt.Fprintf(os.Stderr, "%s: %v\n", id, data)
+				if layer != "" {
+					fmt.Fprintf(os.Stderr, "(from %s)\n", layer)
+				}
+			}
+			if c := oerr.ClassifyIn(db, id); c != 0 {
+				fmt.Fprintf(os.Stderr, "Class: %s\n", c)
+			}
+			if getMap {
+				if err := oerr.LoadClasses(db); err != nil {
+					log.Printf("load classes: %v", err)
+				}
+				m := oerr.MapOf(id)
+				fmt.Fprintf(os.Stderr, "SQLSTATE: %s\nHTTP: %d %s\ngRPC: %s\n",
+					m.SQLState, m.HTTP, http.StatusText(m.HTTP), m.GRPC)
+			}
+			if nl, ok := db.(oerr.NoteLister); ok {
+				notes, err := nl.Notes(id)
+				if err != nil {
+					log.Printf("notes of %s: %v", id, err)
+				}
+				if len(notes) != 0 {
+					fmt.Fprintf(os.Stderr, "Notes:\n")
+					for _, n := range notes {
+						fmt.Fprintf(os.Stderr, "  %s\n", n)
+					}
+				}
 			}
 		},
 	}
+	getCmd.Flags().BoolVarP(&getMap, "map", "m", false, "show the SQLSTATE, HTTP status and gRPC code mapped to the message")
+	getCmd.Flags().StringVarP(&mappingsFile, "mappings", "", mappingsFile, "JSON file of the mappings overriding the built-in ones ($OERR_MAPPINGS)")
 	mainCmd.AddCommand(getCmd)
 
-	if _, _, err := mainCmd.Find(os.Args[1:]); err != nil {
-		mainCmd.SetArgs(append([]string{"get"}, os.Args[1:]...))
+	var listPrefix string
+	listCmd := &cobra.Command{
+		Use:   "list [FROM..TO]",
+		Short: "list messages, all or by prefix or range (ORA-01500..ORA-01600)",
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+
+			var rng string
+			if len(args) != 0 {
+				rng = args[0]
+			}
+			msgs, err := selectMsgs(db, listPrefix, rng)
+			if err != nil {
+				log.Fatal(err)
+			}
+			w := bufio.NewWriter(os.Stdout)
+			defer w.Flush()
+			for msg, err := range msgs {
+				if err != nil {
+					log.Printf("list: %v", err)
+					continue
+				}
+				fmt.Fprintf(w, "%s: %s\n", msg.MsgID, msg.Description)
+			}
+		},
+	}
+	listCmd.Flags().StringVarP(&listPrefix, "prefix", "p", "", "list only messages with this prefix (ORA, PLS, TNS...)")
+	mainCmd.AddCommand(listCmd)
+
+	var putData oerr.MsgData
+	var putForce bool
+	putCmd := &cobra.Command{
+		Use:   "put ID",
+		Short: "put a custom message, or override the given fields of a downloaded one",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := oerr.ParseMsgID(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			w, err := openWriter(dbPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer w.Close()
+
+			data, err := w.Get(id)
+			if err != nil {
+				if err != oerr.ErrNotFound {
+					log.Fatalf("get %s: %v", id, err)
+				}
+				if !putForce && id.Prefix == "ORA" && !oerr.IsApplicationError(id) {
+					log.Fatalf("%s is not in the application error range (ORA-20000..ORA-20999), use --force", id)
+				}
+			}
+			flags := cmd.Flags()
+			if flags.Changed("desc") {
+				data.Description = putData.Description
+			}
+			if flags.Changed("cause") {
+				data.Cause = putData.Cause
+			}
+			if flags.Changed("action") {
+				data.Action = putData.Action
+			}
+			if data.Description == "" {
+				log.Fatalf("%s: description is required", id)
+			}
+			if err := w.Put(oerr.Message{MsgID: id, MsgData: data}); err != nil {
+				log.Fatalf("put %s: %v", id, err)
+			}
+		},
+	}
+	putCmd.Flags().StringVarP(&putData.Description, "desc", "", "", "description")
+	putCmd.Flags().StringVarP(&putData.Cause, "cause", "", "", "cause")
+	putCmd.Flags().StringVarP(&putData.Action, "action", "", "", "action")
+	putCmd.Flags().BoolVarP(&putForce, "force", "f", false, "allow new codes outside of the application error range")
+	mainCmd.AddCommand(putCmd)
+
+	var deleteRevert bool
+	deleteCmd := &cobra.Command{
+		Use:   "delete ID",
+		Short: "delete a message, or revert its override with --revert",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			id, err := oerr.ParseMsgID(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			w, err := openWriter(dbPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer w.Close()
+			if deleteRevert {
+				err = w.Revert(id)
+			} else {
+				err = w.Delete(id)
+			}
+			if err != nil {
+				log.Fatalf("delete %s: %v", id, err)
+			}
+		},
+	}
+	deleteCmd.Flags().BoolVarP(&deleteRevert, "revert", "", false, "revert to the downloaded message")
+	mainCmd.AddCommand(deleteCmd)
+
+	noteCmd := &cobra.Command{
+		Use:   "note",
+		Short: "team notes attached to messages",
+	}
+	mainCmd.AddCommand(noteCmd)
+
+	noteAuthor := os.Getenv("USER")
+	var noteTags []string
+	noteAddCmd := &cobra.Command{
+		Use:   "add ID TEXT...",
+		Short: "add a note to the message",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(_ *cobra.Command, args []string) {
+			id, err := oerr.ParseMsgID(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			w, err := openWriter(dbPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer w.Close()
+			seq, err := w.AddNote(oerr.Note{MsgID: id, Author: noteAuthor, Tags: noteTags, Text: strings.Join(args[1:], " ")})
+			if err != nil {
+				log.Fatalf("add note to %s: %v", id, err)
+			}
+			fmt.Printf("%s #%d\n", id, seq)
+		},
+	}
+	noteAddCmd.Flags().StringVarP(&noteAuthor, "author", "a", noteAuthor, "author of the note")
+	noteAddCmd.Flags().StringSliceVarP(&noteTags, "tag", "t", nil, "tags of the note")
+	noteCmd.AddCommand(noteAddCmd)
+
+	var noteListTags []string
+	noteListCmd := &cobra.Command{
+		Use:   "list [ID]",
+		Short: "list the notes (of the message)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+			nl, ok := db.(oerr.NoteLister)
+			if !ok {
+				log.Fatalf("%q does not store notes", dbPath)
+			}
+			notes := nl.AllNotes()
+			if len(args) != 0 {
+				id, err := oerr.ParseMsgID(args[0])
+				if err != nil {
+					log.Fatal(err)
+				}
+				ns, err := nl.Notes(id)
+				if err != nil {
+					log.Fatalf("notes of %s: %v", id, err)
+				}
+				notes = func(yield func(oerr.Note, error) bool) {
+					for _, n := range ns {
+						if !yield(n, nil) {
+							return
+						}
+					}
+				}
+			}
+			for n, err := range notes {
+				if err != nil {
+					log.Fatal(err)
+				}
+				if len(noteListTags) != 0 && !slices.ContainsFunc(noteListTags, func(tag string) bool { return slices.Contains(n.Tags, tag) }) {
+					continue
+				}
+				fmt.Printf("%s %s\n", n.MsgID, n)
+			}
+		},
+	}
+	noteListCmd.Flags().StringSliceVarP(&noteListTags, "tag", "t", nil, "list only notes with any of these tags")
+	noteCmd.AddCommand(noteListCmd)
+
+	noteCmd.AddCommand(&cobra.Command{
+		Use:   "rm ID SEQ",
+		Short: "remove the note of the message with the given sequence number",
+		Args:  cobra.ExactArgs(2),
+		Run: func(_ *cobra.Command, args []string) {
+			id, err := oerr.ParseMsgID(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			seq, err := strconv.ParseUint(strings.TrimPrefix(args[1], "#"), 10, 64)
+			if err != nil {
+				log.Fatalf("parse %q: %v", args[1], err)
+			}
+			w, err := openWriter(dbPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer w.Close()
+			if err := w.DeleteNote(id, seq); err != nil {
+				log.Fatalf("rm note %s #%d: %v", id, seq, err)
+			}
+		},
+	})
+
+	classCmd := &cobra.Command{
+		Use:   "class",
+		Short: "classification of the messages: " + strings.Join(oerr.ClassNames(), ", "),
+	}
+	mainCmd.AddCommand(classCmd)
+
+	classCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list the classified messages, the built-in and the stored ones",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+			if err := oerr.LoadClasses(db); err != nil {
+				log.Fatalf("load classes: %v", err)
+			}
+			classes := oerr.Classes()
+			ids := slices.SortedFunc(maps.Keys(classes), oerr.MsgID.Compare)
+			w := bufio.NewWriter(os.Stdout)
+			defer w.Flush()
+			for _, id := range ids {
+				fmt.Fprintf(w, "%s\t%s\n", id, classes[id])
+			}
+		},
+	})
+
+	classCmd.AddCommand(&cobra.Command{
+		Use:   "set ID CLASS...",
+		Short: "store the classes of the message (none for no class), overriding the built-in ones",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(_ *cobra.Command, args []string) {
+			id, err := oerr.ParseMsgID(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			c, err := oerr.ParseClass(strings.Join(args[1:], ","))
+			if err != nil {
+				log.Fatal(err)
+			}
+			w, err := openWriter(dbPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer w.Close()
+			if err := w.SetClass(id, c); err != nil {
+				log.Fatalf("set class of %s: %v", id, err)
+			}
+		},
+	})
+
+	classCmd.AddCommand(&cobra.Command{
+		Use:   "rm ID",
+		Short: "delete the stored classes of the message, reverting to the built-in ones",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			id, err := oerr.ParseMsgID(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			w, err := openWriter(dbPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer w.Close()
+			if err := w.DeleteClass(id); err != nil {
+				log.Fatalf("rm class of %s: %v", id, err)
+			}
+		},
+	})
+
+	dumpCmd := &cobra.Command{
+		Use:   "dump OUT",
+		Short: "dump the DB into a compressed catalog, to be embedded with the oerr_embed build tag",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+			fh, err := os.Create(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := oerr.WriteCatalog(fh, db.Meta(), db.All()); err != nil {
+				fh.Close()
+				log.Fatalf("dump into %q: %v", args[0], err)
+			}
+			if err := fh.Close(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	mainCmd.AddCommand(dumpCmd)
+
+	packCmd := &cobra.Command{
+		Use:   "pack OUT",
+		Short: "pack the DB into the compact, read-only format",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+			fh, err := os.Create(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := oerr.WritePack(fh, db.Meta(), db.All()); err != nil {
+				fh.Close()
+				log.Fatalf("pack into %q: %v", args[0], err)
+			}
+			if err := fh.Close(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	mainCmd.AddCommand(packCmd)
+
+	var exportFormat, exportPrefix, exportRange, exportOut string
+	exportCmd := &cobra.Command{
+		Use:   "export [OUT]",
+		Short: "export the messages as " + strings.Join(oerr.Formats, ", ") + " or into a SQLite DB",
+		Long: `Export the messages with their provenance.
+
+The sqlite format writes a new SQLite DB (metadata, releases, prefixes and messages tables,
+and the messages_fts full text index).`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			if len(args) != 0 {
+				exportOut = args[0]
+			}
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+
+			msgs, err := selectMsgs(db, exportPrefix, exportRange)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if exportFormat == "sqlite" {
+				if exportOut == "" || exportOut == "-" {
+					log.Fatal("the sqlite format needs an output file")
+				}
+				if err := sqlitestore.Export(exportOut, db, msgs); err != nil {
+					log.Fatalf("export into %q: %v", exportOut, err)
+				}
+				return
+			}
+			w := io.Writer(os.Stdout)
+			if exportOut != "" && exportOut != "-" {
+				fh, err := os.Create(exportOut)
+				if err != nil {
+					log.Fatal(err)
+				}
+				defer func() {
+					if err := fh.Close(); err != nil {
+						log.Fatal(err)
+					}
+				}()
+				w = fh
+			}
+			if err := oerr.Export(w, exportFormat, db, msgs); err != nil {
+				log.Fatalf("export: %v", err)
+			}
+		},
+	}
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "jsonl", "output format: "+strings.Join(oerr.Formats, ", ")+", sqlite")
+	exportCmd.Flags().StringVarP(&exportPrefix, "prefix", "p", "", "export only messages with this prefix")
+	exportCmd.Flags().StringVarP(&exportRange, "range", "r", "", "export only messages in this range (ORA-01500..ORA-01600)")
+	exportCmd.Flags().StringVarP(&exportOut, "output", "o", "", "output file (default stdout)")
+	mainCmd.AddCommand(exportCmd)
+
+	var importFormat string
+	var importReplace, importOverride, importDryRun bool
+	importCmd := &cobra.Command{
+		Use:   "import FILE...",
+		Short: "import messages from json, jsonl or csv files, merging them into the catalog",
+		Long: `Import messages from json, jsonl or csv files (as written by export).
+
+By default the messages are merged into the catalog of the first DB of the search path,
+--replace replaces the whole catalog, --override adds them as custom messages (see put).
+All the records are validated first, and nothing is written if any of them is bad.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			if importReplace && importOverride {
+				log.Fatal("--replace and --override are mutually exclusive")
+			}
+			var msgs []oerr.Message
+			var bad int
+			for _, fn := range args {
+				format := importFormat
+				if format == "" {
+					if format = oerr.FormatOf(fn); format == "" {
+						log.Fatalf("%s: unsupported format %q, use --format json, jsonl or csv", fn, filepath.Ext(fn))
+					}
+				}
+				fh, err := os.Open(fn)
+				if err != nil {
+					log.Fatal(err)
+				}
+				for rec, err := range oerr.DecodeRecords(bufio.NewReader(fh), format) {
+					var re *oerr.RecordError
+					if errors.As(err, &re) {
+						bad++
+						fmt.Fprintf(os.Stderr, "%s:%v\n", fn, re)
+						continue
+					}
+					if err != nil {
+						fh.Close()
+						log.Fatalf("%s: %v", fn, err)
+					}
+					msgs = append(msgs, rec.Message())
+				}
+				fh.Close()
+			}
+			if bad != 0 {
+				log.Fatalf("%d bad records, nothing imported", bad)
+			}
+			if importDryRun {
+				fmt.Printf("%d messages are valid\n", len(msgs))
+				return
+			}
+
+			seq := func(yield func(oerr.Message, error) bool) {
+				for _, msg := range msgs {
+					if !yield(msg, nil) {
+						return
+					}
+				}
+			}
+			if importOverride {
+				w, err := openWriter(dbPath)
+				if err != nil {
+					log.Fatalf("Open %q: %v", dbPath, err)
+				}
+				defer w.Close()
+				n, err := w.Load(seq)
+				if err != nil {
+					log.Fatalf("import: %v", err)
+				}
+				fmt.Printf("%d messages imported as overrides\n", n)
+				return
+			}
+			source, now := strings.Join(args, " "), time.Now().UTC().Format(time.RFC3339)
+			meta := map[string]string{oerr.MetaImported: source, oerr.MetaImportedAt: now}
+			dbURL := oerr.SplitSearchPath(dbPath)[0]
+			importFunc := oerr.MergeURL
+			if importReplace {
+				meta = map[string]string{oerr.MetaURL: source, oerr.MetaCreated: now}
+				importFunc = oerr.ReplaceURL
+			}
+			if err := importFunc(dbURL, meta, seq); err != nil {
+				log.Fatalf("import into %q: %v", dbURL, err)
+			}
+			fmt.Printf("%d messages imported\n", len(msgs))
+		},
+	}
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "input format: json, jsonl or csv (default by the file extension)")
+	importCmd.Flags().BoolVarP(&importReplace, "replace", "", false, "replace the catalog instead of merging")
+	importCmd.Flags().BoolVarP(&importOverride, "override", "", false, "import as overrides, which survive a re-download")
+	importCmd.Flags().BoolVarP(&importDryRun, "dry-run", "n", false, "only validate the files")
+	mainCmd.AddCommand(importCmd)
+
+	genCmd := &cobra.Command{
+		Use:   "gen",
+		Short: "generate source code from the catalog",
+	}
+	mainCmd.AddCommand(genCmd)
+
+	var genOpts gen.OracleOptions
+	var genOut, genPrefix, genRange string
+	var genLoader bool
+	genOracleCmd := &cobra.Command{
+		Use:   "oracle",
+		Short: "generate the Oracle table, its data and a PL/SQL lookup package",
+		Long: `Generate the Oracle table, its data and a PL/SQL package with the
+describe, cause and action functions, into the output directory:
+
+  TABLE.sql            CREATE TABLE
+  TABLE_data.sql       batched INSERTs, or with --loader
+  TABLE.ctl, TABLE.dat the SQL*Loader control and data files
+  PACKAGE.pks, .pkb    the PL/SQL package
+  install.sql          SQL*Plus script running the above`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+
+			msgs, err := selectMsgs(db, genPrefix, genRange)
+			if err != nil {
+				log.Fatal(err)
+			}
+			genOpts.Header = "Generated by oerr gen oracle. DO NOT EDIT."
+			meta := db.Meta()
+			if u := meta[oerr.MetaURL]; u != "" {
+				genOpts.Header += "\nSource: " + u
+			}
+			if c := meta[oerr.MetaCreated]; c != "" {
+				genOpts.Header += "\nCreated: " + c
+			}
+			if err := os.MkdirAll(genOut, 0755); err != nil {
+				log.Fatal(err)
+			}
+			table, pkg := strings.ToLower(genOpts.Table), strings.ToLower(genOpts.Package)
+			if table == "" {
+				table = "oerr_message"
+			}
+			if pkg == "" {
+				pkg = "oerr"
+			}
+			create := func(name string) *os.File {
+				fh, err := os.Create(filepath.Join(genOut, name))
+				if err != nil {
+					log.Fatal(err)
+				}
+				return fh
+			}
+			finish := func(err error, fhs ...*os.File) {
+				for _, fh := range fhs {
+					if closeErr := fh.Close(); err == nil {
+						err = closeErr
+					}
+				}
+				if err != nil {
+					log.Fatalf("gen oracle: %v", err)
+				}
+			}
+			fh := create(table + ".sql")
+			finish(gen.OracleTable(fh, genOpts), fh)
+			install := []string{"@@" + table + ".sql"}
+			if genLoader {
+				ctl, dat := create(table+".ctl"), create(table+".dat")
+				finish(gen.OracleLoader(ctl, dat, table+".dat", genOpts, msgs), ctl, dat)
+				install = append(install, "PROMPT Load the data with: sqlldr control="+table+".ctl")
+			} else {
+				fh = create(table + "_data.sql")
+				finish(gen.OracleInserts(fh, genOpts, msgs), fh)
+				install = append(install, "@@"+table+"_data.sql")
+			}
+			spec, body := create(pkg+".pks"), create(pkg+".pkb")
+			finish(gen.OraclePackage(spec, body, genOpts), spec, body)
+			install = append(install, "@@"+pkg+".pks", "@@"+pkg+".pkb")
+			fh = create("install.sql")
+			_, err = io.WriteString(fh, strings.Join(install, "\n")+"\n")
+			finish(err, fh)
+		},
+	}
+	genOracleCmd.Flags().StringVarP(&genOut, "output", "o", ".", "output directory")
+	genOracleCmd.Flags().StringVarP(&genOpts.Table, "table", "", "", "table name (default OERR_MESSAGE)")
+	genOracleCmd.Flags().StringVarP(&genOpts.Package, "package", "", "", "PL/SQL package name (default OERR)")
+	genOracleCmd.Flags().IntVarP(&genOpts.Batch, "batch", "", 100, "rows per INSERT ALL")
+	genOracleCmd.Flags().BoolVarP(&genLoader, "loader", "", false, "SQL*Loader control and data files instead of INSERTs")
+	genOracleCmd.Flags().StringVarP(&genPrefix, "prefix", "p", "", "only messages with this prefix")
+	genOracleCmd.Flags().StringVarP(&genRange, "range", "r", "", "only messages in this range (ORA-01500..ORA-01600)")
+	genCmd.AddCommand(genOracleCmd)
+
+	var genGoOpts gen.GoOptions
+	var genGoOut, genGoOnly string
+	genGoCmd := &cobra.Command{
+		Use:   "go",
+		Short: "generate a Go package with named constants and sentinel errors for the codes",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+
+			msgs := db.All()
+			if genGoOnly != "" {
+				ids, err := readMsgIDs(genGoOnly)
+				if err != nil {
+					log.Fatal(err)
+				}
+				only := make([]oerr.Message, 0, len(ids))
+				for _, id := range ids {
+					data, err := db.Get(id)
+					if err != nil {
+						log.Fatalf("%s: %s: %v", genGoOnly, id, err)
+					}
+					only = append(only, oerr.Message{MsgID: id, MsgData: data})
+				}
+				msgs = func(yield func(oerr.Message, error) bool) {
+					for _, msg := range only {
+						if !yield(msg, nil) {
+							return
+						}
+					}
+				}
+			}
+			meta := db.Meta()
+			if u := meta[oerr.MetaURL]; u != "" {
+				genGoOpts.Header = "Source: " + u
+			}
+			if c := meta[oerr.MetaCreated]; c != "" {
+				genGoOpts.Header += "\nCreated: " + c
+			}
+			w := io.Writer(os.Stdout)
+			if genGoOut != "" && genGoOut != "-" {
+				fh, err := os.Create(genGoOut)
+				if err != nil {
+					log.Fatal(err)
+				}
+				defer func() {
+					if err := fh.Close(); err != nil {
+						log.Fatal(err)
+					}
+				}()
+				w = fh
+			}
+			if err := gen.Go(w, genGoOpts, msgs); err != nil {
+				log.Fatalf("gen go: %v", err)
+			}
+		},
+	}
+	genGoCmd.Flags().StringVarP(&genGoOpts.Package, "pkg", "", "oraerr", "package name")
+	genGoCmd.Flags().StringVarP(&genGoOnly, "only", "", "", "file of the codes to generate (one per line, # comments)")
+	genGoCmd.Flags().StringVarP(&genGoOut, "output", "o", "", "output file (default stdout)")
+	genCmd.AddCommand(genGoCmd)
+
+	var statsJSON bool
+	var statsTop int
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "print statistics of the DB: counts per prefix and chapter, missing texts, metadata",
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+
+			st, err := oerr.GetStats(db, statsTop)
+			if err != nil {
+				log.Fatalf("stats: %v", err)
+			}
+			st.Path = dbPath
+			for _, u := range oerr.SplitSearchPath(dbPath) {
+				_, p := oerr.SplitURL(u)
+				if fi, err := os.Stat(p); err == nil {
+					st.Size += fi.Size()
+				}
+			}
+			if statsJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(st); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+			printStats(os.Stdout, st)
+		},
+	}
+	statsCmd.Flags().BoolVarP(&statsJSON, "json", "", false, "JSON output")
+	statsCmd.Flags().IntVarP(&statsTop, "top", "n", 10, "number of longest texts to report")
+	mainCmd.AddCommand(statsCmd)
+
+	var explainSrc string
+	var explainContext int
+	explainCmd := &cobra.Command{
+		Use:   "explain [FILE...]",
+		Short: "explain the error stack read from the files or stdin: the errors, their classes and the PL/SQL frames",
+		Run: func(_ *cobra.Command, args []string) {
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+			if err := oerr.LoadClasses(db); err != nil {
+				log.Printf("load classes: %v", err)
+			}
+			var src *oerr.SourceTree
+			if explainSrc != "" {
+				if src, err = oerr.LoadSourceTree(explainSrc); err != nil {
+					log.Fatalf("load sources from %q: %v", explainSrc, err)
+				}
+			}
+
+			var r io.Reader = os.Stdin
+			if len(args) != 0 {
+				var rs []io.Reader
+				for _, fn := range args {
+					fh, err := os.Open(fn)
+					if err != nil {
+						log.Fatal(err)
+					}
+					defer fh.Close()
+					rs = append(rs, fh)
+				}
+				r = io.MultiReader(rs...)
+			}
+			st, err := oerr.ReadStack(r, db)
+			if err != nil {
+				log.Fatalf("read stack: %v", err)
+			}
+			if len(st.Errors) == 0 && len(st.Frames) == 0 {
+				log.Fatal("no error codes found")
+			}
+			w := bufio.NewWriter(os.Stdout)
+			defer w.Flush()
+			printStack(w, st, src, explainContext)
+		},
+	}
+	explainCmd.Flags().StringVarP(&explainSrc, "src", "", "", "directory of the PL/SQL sources (.pks, .pkb, .sql) to show the lines of the frames from")
+	explainCmd.Flags().IntVarP(&explainContext, "context", "C", 3, "number of source lines to show before and after the line of a frame")
+	mainCmd.AddCommand(explainCmd)
+
+	var annotateFollow, annotateFromEnd, annotateCause, annotateCompact, annotateOnce bool
+	annotateColor := "auto"
+	annotateCmd := &cobra.Command{
+		Use:   "annotate [FILE...]",
+		Short: "copy the files or stdin, adding the descriptions of the codes after the lines containing them",
+		Run: func(_ *cobra.Command, args []string) {
+			if annotateFollow && len(args) == 0 {
+				log.Fatal("--follow needs files")
+			}
+			db, err := openDB(dbPath)
+			if err != nil {
+				log.Fatalf("Open %q: %v", dbPath, err)
+			}
+			defer db.Close()
+			if err := oerr.LoadClasses(db); err != nil {
+				log.Printf("load classes: %v", err)
+			}
+			scanner, err := oerr.NewDBScanner(db)
+			if err != nil {
+				log.Fatal(err)
+			}
+			a := &annotator{DB: db, Scanner: scanner,
+				Cause: annotateCause, Compact: annotateCompact, Once: annotateOnce}
+			switch annotateColor {
+			case "always":
+				a.Color = true
+			case "never":
+			case "auto":
+				fi, err := os.Stdout.Stat()
+				a.Color = err == nil && fi.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == ""
+			default:
+				log.Fatalf("--color must be auto, always or never, not %q", annotateColor)
+			}
+
+			// buffered, to flush the output only when no more lines are waiting
+			lines := make(chan string, 256)
+			readLines := func(r io.Reader) error {
+				br := bufio.NewReader(r)
+				for {
+					line, err := br.ReadString('\n')
+					if line != "" {
+						lines <- line
+					}
+					if err != nil {
+						if err == io.EOF {
+							return nil
+						}
+						return err
+					}
+				}
+			}
+			var wg sync.WaitGroup
+			if annotateFollow {
+				ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+				defer cancel()
+				for _, fn := range args {
+					f, err := oerr.FollowFile(ctx, fn, oerr.FollowOptions{FromEnd: annotateFromEnd,
+						OnRotate: func(path string) { log.Printf("%s: rotated", path) }})
+					if err != nil {
+						log.Fatal(err)
+					}
+					defer f.Close()
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if err := readLines(f); err != nil && !errors.Is(err, context.Canceled) {
+							log.Printf("%s: %v", fn, err)
+						}
+					}()
+				}
+			} else {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if len(args) == 0 {
+						if err := readLines(os.Stdin); err != nil {
+							log.Printf("stdin: %v", err)
+						}
+						return
+					}
+					for _, fn := range args {
+						fh, err := os.Open(fn)
+						if err != nil {
+							log.Print(err)
+							continue
+						}
+						if err := readLines(fh); err != nil {
+							log.Printf("%s: %v", fn, err)
+						}
+						fh.Close()
+					}
+				}()
+			}
+			go func() { wg.Wait(); close(lines) }()
+
+			w := bufio.NewWriter(os.Stdout)
+			defer w.Flush()
+			for line := range lines {
+				a.Annotate(w, line)
+				if len(lines) == 0 {
+					w.Flush()
+				}
+			}
+		},
+	}
+	annotateCmd.Flags().BoolVarP(&annotateFollow, "follow", "f", false, "follow the files as tail -F does, reopening them when rotated")
+	annotateCmd.Flags().BoolVarP(&annotateFromEnd, "from-end", "", false, "follow from the end of the files, not from their start")
+	annotateCmd.Flags().BoolVarP(&annotateCause, "cause", "c", false, "add the causes and actions, too")
+	annotateCmd.Flags().BoolVarP(&annotateCompact, "compact", "", false, "add the descriptions of all the codes of the line in one line")
+	annotateCmd.Flags().BoolVarP(&annotateOnce, "once", "", false, "annotate only the first occurrence of each code")
+	annotateCmd.Flags().StringVarP(&annotateColor, "color", "", annotateColor, "colorize the annotations: auto, always or never")
+	mainCmd.AddCommand(annotateCmd)
+
+	args := sqlcodeArgs(slices.Clone(os.Args[1:]),
+		func(name string) bool { c, _, err := mainCmd.Find([]string{name}); return err == nil && c != mainCmd },
+		mainCmd.PersistentFlags(), getCmd.Flags())
+	mainCmd.SetArgs(args)
+	if _, _, err := mainCmd.Find(args); err != nil {
+		mainCmd.SetArgs(append([]string{"get"}, args...))
 	}
 	mainCmd.Execute()
 }
+
+// sqlcodeArgs moves the first SQLCODE (-1) of get, which is not a flag, after --.
+// Without a subcommand (isCommand), get is prepended.
+// The flags are used to skip the flag values.
+func sqlcodeArgs(args []string, isCommand func(string) bool, flags ...*pflag.FlagSet) []string {
+	isSQLCode := func(a string) bool {
+		return len(a) > 1 && a[0] == '-' && strings.Trim(a[1:], "0123456789") == ""
+	}
+	takesValue := func(a string) bool {
+		if strings.Contains(a, "=") {
+			return false
+		}
+		if name, ok := strings.CutPrefix(a, "--"); ok {
+			for _, fs := range flags {
+				if f := fs.Lookup(name); f != nil {
+					return f.NoOptDefVal == ""
+				}
+			}
+			return false
+		}
+		// -mD path: the first flag with a value takes the rest, or the next argument
+		for k, c := range a[1:] {
+			for _, fs := range flags {
+				if f := fs.ShorthandLookup(string(c)); f != nil && f.NoOptDefVal == "" {
+					return k == len(a)-2
+				}
+			}
+		}
+		return false
+	}
+	get, code := false, -1
+	for i := 0; i < len(args) && code < 0; i++ {
+		switch a := args[i]; {
+		case a == "--":
+			i = len(args)
+		case isSQLCode(a):
+			code = i
+		case strings.HasPrefix(a, "-"):
+			if takesValue(a) {
+				i++
+			}
+		case !get && isCommand(a):
+			if a != "get" {
+				return args
+			}
+			get = true
+		}
+	}
+	if code < 0 {
+		return args
+	}
+	a := args[code]
+	args = slices.Delete(args, code, code+1)
+	if j := slices.Index(args[code:], "--"); j >= 0 {
+		args = slices.Insert(args, code+j+1, a)
+	} else {
+		args = append(args, "--", a)
+	}
+	if !get {
+		args = append([]string{"get"}, args...)
+	}
+	return args
+}
+
+// readMsgIDs reads the message IDs from the file, separated by spaces, commas or newlines,
+// skipping # comments. The IDs are sorted and deduplicated.
+func readMsgIDs(fileName string) ([]oerr.MsgID, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var ids []oerr.MsgID
+	for i, line := range strings.Split(string(b), "\n") {
+		if j := strings.IndexByte(line, '#'); j >= 0 {
+			line = line[:j]
+		}
+		for _, f := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\r' }) {
+			id, err := oerr.ParseMsgID(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", fileName, i+1, err)
+			}
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, oerr.MsgID.Compare)
+	return slices.Compact(ids), nil
+}
+
+// selectMsgs returns the messages with the prefix or in the range (FROM..TO),
+// all of them if both are empty.
+func selectMsgs(db oerr.DB, prefix, rng string) (iter.Seq2[oerr.Message, error], error) {
+	switch {
+	case prefix != "" && rng != "":
+		return nil, errors.New("a prefix and a range cannot be given together")
+	case prefix != "":
+		return db.Prefix(prefix), nil
+	case rng != "":
+		from, to, err := parseRange(rng)
+		if err != nil {
+			return nil, err
+		}
+		return db.Range(from, to), nil
+	}
+	return db.All(), nil
+}
+
+// parseRange parses FROM..TO, where TO may be empty (till the end of the FROM prefix).
+func parseRange(txt string) (from, to oerr.MsgID, err error) {
+	i := strings.Index(txt, "..")
+	if i < 0 {
+		from, err = oerr.ParseMsgID(txt)
+		return from, from, err
+	}
+	if from, err = oerr.ParseMsgID(txt[:i]); err != nil {
+		return from, to, err
+	}
+	if txt[i+2:] == "" {
+		return from, oerr.MaxID(from.Prefix), nil
+	}
+	to, err = oerr.ParseMsgID(txt[i+2:])
+	return from, to, err
+}
+
+func printStats(w io.Writer, st oerr.Stats) {
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	fmt.Fprintf(bw, "DB: %s (%d bytes)\n", st.Path, st.Size)
+	for _, k := range sortedKeys(st.Meta) {
+		fmt.Fprintf(bw, "  %s: %s\n", k, st.Meta[k])
+	}
+	fmt.Fprintf(bw, "Messages: %d\n", st.Total)
+	fmt.Fprintf(bw, "Prefixes:\n")
+	for _, k := range sortedKeys(st.Prefixes) {
+		fmt.Fprintf(bw, "  %s\t%d\n", k, st.Prefixes[k])
+	}
+	fmt.Fprintf(bw, "Chapters:\n")
+	for _, k := range sortedKeys(st.Chapters) {
+		fmt.Fprintf(bw, "  %s\t%d\n", k, st.Chapters[k])
+	}
+	fmt.Fprintf(bw, "Missing cause: %d\n", len(st.NoCause))
+	fmt.Fprintf(bw, "Missing action: %d\n", len(st.NoAction))
+	if len(st.Longest) != 0 {
+		fmt.Fprintf(bw, "Longest:\n")
+		for _, tl := range st.Longest {
+			fmt.Fprintf(bw, "  %s\t%d\n", tl.ID, tl.Length)
+		}
+	}
+}
+
+// printStack prints the errors of the stack with their catalog data, and the frames of them,
+// with their source lines, if src is not nil.
+func printStack(w io.Writer, st *oerr.Stack, src *oerr.SourceTree, context int) {
+	indent := func(s string) string { return strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n    ") }
+	printFrames := func(i int) {
+		for _, f := range st.FramesOf(i) {
+			if f.IsAnonymous() {
+				fmt.Fprintf(w, "  at anonymous block, line %d\n", f.Line)
+			} else {
+				fmt.Fprintf(w, "  at %s\n", f)
+			}
+			if src != nil && !f.IsAnonymous() {
+				printSource(w, src, f, context)
+			}
+		}
+	}
+	printFrames(-1)
+	for i, e := range st.Errors {
+		fmt.Fprint(w, e.Error())
+		if c := oerr.Classify(e.ID); c != 0 {
+			fmt.Fprintf(w, "  [%s]", c)
+		}
+		fmt.Fprintln(w)
+		data, err := e.Data()
+		if err != nil {
+			fmt.Fprintf(w, "  (catalog: %v)\n", err)
+		} else {
+			t := data.Template()
+			if args, ok := t.Match(e.Text); !ok {
+				fmt.Fprintf(w, "  Description: %s\n", indent(data.Description))
+			} else if len(args) != 0 {
+				names := make([]string, len(args))
+				for j, a := range args {
+					names[j] = t.Args[j].Name + "=" + a
+				}
+				fmt.Fprintf(w, "  Args: %s\n", strings.Join(names, ", "))
+			}
+			if data.Cause != "" {
+				fmt.Fprintf(w, "  Cause: %s\n", indent(data.Cause))
+			}
+			if data.Action != "" {
+				fmt.Fprintf(w, "  Action: %s\n", indent(data.Action))
+			}
+		}
+		printFrames(i)
+	}
+	if len(st.Frames) != 0 && st.Frames[0].Error >= 0 {
+		f := st.Frames[0]
+		fmt.Fprintf(w, "\n%s raised at %s\n", st.Errors[f.Error].ID, f)
+	}
+}
+
+// printSource prints the lines of the frame from its source file.
+func printSource(w io.Writer, src *oerr.SourceTree, f oerr.Frame, context int) {
+	u, ok := src.Find(f)
+	if !ok {
+		fmt.Fprintf(w, "    (no source of %s)\n", f.Name())
+		return
+	}
+	target := u.FileLine(f.Line)
+	fmt.Fprintf(w, "    %s:%d (%s %s)\n", u.Path, target, u.Type, u.Name)
+	lines, err := u.Lines(f.Line, context)
+	if err != nil {
+		fmt.Fprintf(w, "    (%v)\n", err)
+		return
+	}
+	if len(lines) == 0 || lines[len(lines)-1].No < target {
+		fmt.Fprintf(w, "    (%s has no line %d)\n", u.Path, target)
+		return
+	}
+	width := len(strconv.Itoa(lines[len(lines)-1].No))
+	for _, l := range lines {
+		mark := " "
+		if l.No == target {
+			mark = ">"
+		}
+		fmt.Fprintf(w, "    %s %*d | %s\n", mark, width, l.No, l.Text)
+	}
+}
+
+const (
+	colorCode   = "\x1b[1;33m"
+	colorDesc   = "\x1b[36m"
+	colorDetail = "\x1b[2m"
+	colorReset  = "\x1b[0m"
+)
+
+// annotator adds the descriptions of the codes after the lines containing them.
+// The ORA-06512 frames are not annotated, as their descriptions tell nothing.
+type annotator struct {
+	DB      oerr.DB
+	Scanner *oerr.Scanner
+	// Cause adds the causes and actions, Compact adds one line for all the codes of a line,
+	// Once annotates only the first occurrence of each code.
+	Cause, Compact, Once, Color bool
+
+	seen map[oerr.MsgID]bool
+}
+
+// Annotate writes the line and the descriptions of its codes.
+func (a *annotator) Annotate(w io.Writer, line string) {
+	line = strings.TrimRight(line, "\r\n")
+	fmt.Fprintln(w, line)
+	if a.seen == nil {
+		a.seen = make(map[oerr.MsgID]bool)
+	}
+	paint := func(color, s string) string {
+		if !a.Color {
+			return s
+		}
+		return color + s + colorReset
+	}
+	var notes []string
+	inLine := make(map[oerr.MsgID]bool)
+	for _, occ := range a.Scanner.FindAll(line) {
+		if occ.ID == oerr.FrameID || inLine[occ.ID] || a.Once && a.seen[occ.ID] {
+			continue
+		}
+		inLine[occ.ID] = true
+		data, err := a.DB.Get(occ.ID)
+		if err != nil {
+			continue
+		}
+		a.seen[occ.ID] = true
+		note := paint(colorCode, occ.ID.String()) + ": " + paint(colorDesc, data.Description)
+		if c := oerr.Classify(occ.ID); c != 0 {
+			note += " [" + c.String() + "]"
+		}
+		if a.Compact {
+			notes = append(notes, note)
+			continue
+		}
+		fmt.Fprintf(w, "  -> %s\n", note)
+		if !a.Cause {
+			continue
+		}
+		for _, kv := range [][2]string{{"Cause", data.Cause}, {"Action", data.Action}} {
+			if kv[1] != "" {
+				text := strings.ReplaceAll(strings.TrimSpace(kv[1]), "\n", "\n     ")
+				fmt.Fprintf(w, "     %s\n", paint(colorDetail, kv[0]+": "+text))
+			}
+		}
+	}
+	if len(notes) != 0 {
+		fmt.Fprintf(w, "  -> %s\n", strings.Join(notes, "; "))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// openDB opens the DB, or the layered DBs if dbPath is a search path,
+// falling back to the embedded catalog.
+func openDB(dbPath string) (oerr.DB, error) {
+	return oerr.OpenOrEmbedded(dbPath, oerr.DefaultOptions)
+}
+
+// openWriter opens the first DB of the search path for writing.
+func openWriter(dbPath string) (*oerr.Writer, error) {
+	scheme, p := oerr.SplitURL(oerr.SplitSearchPath(dbPath)[0])
+	if scheme != "" && scheme != "bolt" {
+		return nil, fmt.Errorf("%s: only Bolt DBs are writable", dbPath)
+	}
+	return oerr.OpenWriter(p, oerr.DefaultOptions)
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// Copyright Tamás Gulácsi 2015. All rights reserved.
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	oerr "github.com/tgulacsi/oerr/lib"
+)
+
+func TestSQLCodeArgs(t *testing.T) {
+	persistent := pflag.NewFlagSet("oerr", pflag.ContinueOnError)
+	persistent.StringP("db", "D", "oerr.db", "")
+	persistent.Duration("timeout", time.Second, "")
+	getFlags := pflag.NewFlagSet("get", pflag.ContinueOnError)
+	getFlags.BoolP("map", "m", false, "")
+	isCommand := func(name string) bool { return name == "get" || name == "list" }
+
+	for in, want := range map[string]string{
+		"-1":                     "get -- -1",
+		"1403":                   "1403",
+		"-1 -m":                  "get -m -- -1",
+		"-D t.db -1":             "get -D t.db -- -1",
+		"--db t.db -1":           "get --db t.db -- -1",
+		"--db=t.db -1":           "get --db=t.db -- -1",
+		"-mD t.db -1":            "get -mD t.db -- -1",
+		"-Dt.db -1":              "get -Dt.db -- -1",
+		"--timeout 1s -D -1 -60": "get --timeout 1s -D -1 -- -60",
+		"get -1":                 "get -- -1",
+		"get -m -1":              "get -m -- -1",
+		"-D t.db get -1":         "-D t.db get -- -1",
+		"get -1 -- x":            "get -- -1 x",
+		"list -1":                "list -1",
+		"get -- -1":              "get -- -1",
+	} {
+		got := sqlcodeArgs(strings.Fields(in), isCommand, persistent, getFlags)
+		if !slices.Equal(got, strings.Fields(want)) {
+			t.Errorf("%q: got %q, wanted %q", in, got, want)
+		}
+	}
+}
+
+func TestSelectMsgs(t *testing.T) {
+	db, err := oerr.NewMemDB(nil, func(yield func(oerr.Message, error) bool) {
+		for _, id := range []oerr.MsgID{{Prefix: "ORA", Code: 1}, {Prefix: "ORA", Code: 60}, {Prefix: "PLS", Code: 201}} {
+			if !yield(oerr.Message{MsgID: id}, nil) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		prefix, rng string
+		want        int
+	}{
+		{"", "", 3},
+		{"PLS", "", 1},
+		{"", "ORA-1..ORA-60", 2},
+		{"PLS", "ORA-1..", -1},
+	} {
+		msgs, err := selectMsgs(db, tc.prefix, tc.rng)
+		if tc.want < 0 {
+			if err == nil {
+				t.Errorf("%q %q: wanted error", tc.prefix, tc.rng)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		for range msgs {
+			n++
+		}
+		if n != tc.want {
+			t.Errorf("%q %q: got %d, wanted %d", tc.prefix, tc.rng, n, tc.want)
+		}
+	}
+}